@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"io.twasyl/devcore/pkg/cache"
+	"io.twasyl/devcore/pkg/config"
+	"io.twasyl/devcore/pkg/output"
+	du "io.twasyl/devcore/pkg/utils"
+)
+
+func init() {
+	rootCmd.AddCommand(buildCacheCommand())
+}
+
+func buildCacheCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of downloaded artifacts",
+	}
+
+	command.AddCommand(buildCacheListCommand())
+	command.AddCommand(buildCachePruneCommand())
+
+	return command
+}
+
+func buildCacheListCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the cached artifacts",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := config.Config.Cache().Entries()
+			if err != nil {
+				return err
+			}
+
+			return cacheEntryPrinter.Print(os.Stdout, entries)
+		},
+	}
+
+	return command
+}
+
+func buildCachePruneCommand() *cobra.Command {
+	var all bool
+	var unusedFor time.Duration
+
+	command := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove the artifacts not used recently from the cache",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			unusedSince := time.Now().Add(-unusedFor)
+			if all {
+				unusedSince = time.Now().Add(time.Hour)
+			}
+
+			pruned, err := config.Config.Cache().Prune(unusedSince)
+			if err != nil {
+				return err
+			}
+
+			var size int64
+			for _, entry := range pruned {
+				size += entry.Size
+			}
+			fmt.Println(fmt.Sprintf("%d artifacts removed, %s freed", len(pruned), du.HumanSize(size)))
+			return nil
+		},
+	}
+	command.Flags().BoolVarP(&all, "all", "a", false, "Remove all the artifacts")
+	command.Flags().DurationVar(&unusedFor, "unused-for", 30*24*time.Hour, "Remove the artifacts not used for this duration")
+
+	return command
+}
+
+var cacheEntryPrinter = output.Printer{
+	Columns: []output.Column{
+		{Header: "URL", Value: func(item interface{}) string {
+			return item.(cache.Entry).URL
+		}},
+		{Header: "SIZE", Value: func(item interface{}) string {
+			return du.HumanSize(item.(cache.Entry).Size)
+		}},
+		{Header: "SHA256", Value: func(item interface{}) string {
+			digest := item.(cache.Entry).Digest
+			if len(digest) > 12 {
+				digest = digest[:12]
+			}
+			return digest
+		}},
+		{Header: "LAST USED", Value: func(item interface{}) string {
+			return item.(cache.Entry).LastUsed.Format(time.RFC3339)
+		}},
+	},
+}
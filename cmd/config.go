@@ -1,8 +1,19 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
 	"github.com/spf13/cobra"
 	"io.twasyl/devcore/pkg/config"
+	"io.twasyl/devcore/pkg/output"
+	du "io.twasyl/devcore/pkg/utils"
 )
 
 func init() {
@@ -16,6 +27,11 @@ func buildConfigCommand() *cobra.Command {
 		Short:   "devcore config management",
 	}
 
+	command.AddCommand(buildConfigViewCommand())
+	command.AddCommand(buildConfigGetCommand())
+	command.AddCommand(buildConfigSetCommand())
+	command.AddCommand(buildConfigEditCommand())
+	command.AddCommand(buildConfigValidateCommand())
 	command.AddCommand(buildRestoreDefaultToolsVersions())
 
 	return command
@@ -42,3 +58,225 @@ func buildRestoreDefaultToolsVersions() *cobra.Command {
 
 	return command
 }
+
+func buildConfigViewCommand() *cobra.Command {
+	showOrigin := false
+	showSecrets := false
+
+	command := &cobra.Command{
+		Use:   "view",
+		Short: "Display the configuration",
+		Long: `Display the configuration, merged from its layers: the system configuration, the team configurations, the
+configuration of the user and the one of the project, then the active profile and the DEVCORE_<KEY> environment variables.
+Secrets, like releases.github-token, are masked unless --show-secrets is given.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !showOrigin {
+				displayed := config.Config
+				if !showSecrets {
+					var err error
+					if displayed, err = config.Config.WithoutSecrets(); err != nil {
+						return err
+					}
+				}
+				printer := output.Printer{Text: func(w io.Writer, wide bool) error {
+					return printConfigValue(displayed)
+				}}
+				return printer.Print(os.Stdout, displayed)
+			}
+
+			settings, err := config.Config.Settings()
+			if err != nil {
+				return err
+			}
+			if !showSecrets {
+				for index := range settings {
+					settings[index].Value = config.MaskSecrets(settings[index].Value, settings[index].Path)
+				}
+			}
+			return settingPrinter.Print(os.Stdout, settings)
+		},
+	}
+
+	command.Flags().BoolVar(&showOrigin, "show-origin", false, "Display each setting with the layer, profile or environment variable setting it")
+	command.Flags().BoolVar(&showSecrets, "show-secrets", false, "Display the secrets, like tokens, in clear text")
+
+	return command
+}
+
+func buildConfigGetCommand() *cobra.Command {
+	showSecrets := false
+
+	command := &cobra.Command{
+		Use:   "get <path>",
+		Short: "Display a value of the configuration",
+		Long: `Display a value of the configuration designated by its dotted path, like jenkins.current-context.
+Elements of lists are designated by their index or their name, like docker-compose.contexts.my-context.file.
+Secrets, like releases.github-token, are masked unless --show-secrets is given.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			value, err := config.Config.Get(args[0])
+			if err != nil {
+				return err
+			}
+			if !showSecrets {
+				value = config.MaskSecrets(value, args[0])
+			}
+			return printConfigValue(value)
+		},
+	}
+
+	command.Flags().BoolVar(&showSecrets, "show-secrets", false, "Display the secrets, like tokens, in clear text")
+
+	return command
+}
+
+func buildConfigSetCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "set <path> <value>",
+		Short: "Change a value of the configuration",
+		Long: `Change a value of the configuration designated by its dotted path, like default-tools-version.kubectl.
+Numbers and booleans are parsed as such, lists and objects are given as JSON.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			updated := config.Config
+			if err := updated.Set(args[0], args[1]); err != nil {
+				return err
+			}
+
+			for _, problem := range updated.Validate() {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", problem)
+			}
+
+			config.Config = updated
+			return config.Save()
+		},
+	}
+
+	return command
+}
+
+func buildConfigEditCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "edit",
+		Short: "Edit the configuration with $VISUAL or $EDITOR",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			content, err := json.MarshalIndent(config.Config, "", "  ")
+			if err != nil {
+				return err
+			}
+
+			file, err := os.CreateTemp("", "devcore-config-*.json")
+			if err != nil {
+				return err
+			}
+			defer os.Remove(file.Name())
+			file.Close()
+
+			if err := os.WriteFile(file.Name(), append(content, '\n'), 0600); err != nil {
+				return err
+			}
+
+			for {
+				if err := runEditor(cmd.Context(), file.Name()); err != nil {
+					return err
+				}
+
+				content, err = os.ReadFile(file.Name())
+				if err != nil {
+					return err
+				}
+
+				edited, err := config.Parse(content)
+				if err == nil {
+					for _, problem := range edited.Validate() {
+						fmt.Fprintf(os.Stderr, "Warning: %s\n", problem)
+					}
+					config.Config = edited
+					return config.Save()
+				}
+
+				fmt.Fprintf(os.Stderr, "The configuration is invalid: %s\n", err)
+				fmt.Print("Edit it again? [Yn] ")
+				answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+				answer = strings.TrimSpace(answer)
+				if err != nil || answer == "n" || answer == "N" {
+					return errors.New("edition aborted, the configuration is left unchanged")
+				}
+			}
+		},
+	}
+
+	return command
+}
+
+func buildConfigValidateCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "validate",
+		Short: "Check the configuration and the files it references",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			problems := config.Config.Validate()
+			for _, problem := range problems {
+				fmt.Println(problem)
+			}
+
+			if len(problems) > 0 {
+				cmd.SilenceUsage = true
+				return errors.New(fmt.Sprintf("%d problem(s) found in the configuration", len(problems)))
+			}
+			fmt.Println("The configuration is valid")
+			return nil
+		},
+	}
+
+	return command
+}
+
+// runEditor opens the file with the editor of the user: $VISUAL, $EDITOR or vi.
+func runEditor(ctx context.Context, file string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// The editor may come with arguments, e.g. "code --wait"
+	args := append(strings.Fields(editor), file)
+	_, err := runner.Run(ctx, du.Command{Name: args[0], Args: args[1:], Stdin: os.Stdin})
+	return err
+}
+
+// printConfigValue prints strings as is and other values as indented JSON.
+func printConfigValue(value interface{}) error {
+	if text, ok := value.(string); ok {
+		fmt.Println(text)
+		return nil
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+var settingPrinter = output.Printer{
+	Columns: []output.Column{
+		{Header: "PATH", Value: func(item interface{}) string {
+			return item.(config.Setting).Path
+		}},
+		{Header: "VALUE", Value: func(item interface{}) string {
+			if value := item.(config.Setting).Value; value != nil {
+				return fmt.Sprint(value)
+			}
+			return ""
+		}},
+		{Header: "ORIGIN", Value: func(item interface{}) string {
+			return item.(config.Setting).Origin
+		}},
+	},
+}
@@ -1,13 +1,18 @@
 package cmd
 
 import (
+	"bufio"
+	gocontext "context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"runtime"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"io.twasyl/devcore/pkg/config"
+	"io.twasyl/devcore/pkg/output"
 	pkg "io.twasyl/devcore/pkg/utils"
 )
 
@@ -39,6 +44,9 @@ func buildDockerComposeContextCommand() *cobra.Command {
 		Use:   "create",
 		Short: "Creates a docker compose context in the CLI",
 		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !config.IsStopPolicy(context.StopPolicy) {
+				return errors.New(fmt.Sprintf("Unknown stop policy '%s', expected one of %s", context.StopPolicy, strings.Join(config.StopPolicies, ", ")))
+			}
 			if _, err := config.Config.DockerCompose.FindContextByName(context.Name); config.IsDockerComposeContextNotFound(err) {
 				if _, err := os.Stat(context.File); os.IsNotExist(err) {
 					return errors.New(fmt.Sprintf("The file %s does not exist", context.File))
@@ -59,6 +67,7 @@ func buildDockerComposeContextCommand() *cobra.Command {
 	createCommand.Flags().StringVarP(&context.Name, "name", "n", "", "The name of the context")
 	createCommand.Flags().StringVarP(&context.Description, "description", "d", "", "The description of the context")
 	createCommand.Flags().StringVarP(&context.File, "file", "f", "", "The docker compose file of the context")
+	createCommand.Flags().StringVar(&context.StopPolicy, "stop-policy", "", fmt.Sprintf("What stopping the context does: %s. Defaults to stop", strings.Join(config.StopPolicies, " or ")))
 	createCommand.MarkFlagRequired("name")
 	createCommand.MarkFlagRequired("file")
 	command.AddCommand(createCommand)
@@ -68,38 +77,20 @@ func buildDockerComposeContextCommand() *cobra.Command {
 		Use:     "list",
 		Aliases: []string{"ls"},
 		Short:   "Lists existing docker compose contexts",
-		Run: func(cmd *cobra.Command, args []string) {
-			for index, context := range config.Config.DockerCompose.Contexts {
-				if verbose {
-					if index == 0 {
-						fmt.Println("")
-					}
-					if context.Name == config.Config.DockerCompose.CurrentContext {
-						fmt.Println(fmt.Sprintf("* Name: %s", context.Name))
-					} else {
-						fmt.Println(fmt.Sprintf("  Name: %s", context.Name))
-					}
-					fmt.Println(fmt.Sprintf("  Description: %s", context.Description))
-					fmt.Println(fmt.Sprintf("  File: %s", context.File))
-
-					if index < len(config.Config.DockerCompose.Contexts)-1 {
-						fmt.Println("")
-					}
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if verbose {
+				output.Selected = output.Format{Name: output.Wide}
+			}
 
-					if index == len(config.Config.DockerCompose.Contexts)-1 {
-						fmt.Println("")
-					}
-				} else {
-					if context.Name == config.Config.DockerCompose.CurrentContext {
-						fmt.Println(fmt.Sprintf("* %s", context.Name))
-					} else {
-						fmt.Println(fmt.Sprintf("  %s", context.Name))
-					}
-				}
+			contexts := make([]dockerComposeContextItem, 0)
+			for _, context := range config.Config.DockerCompose.Contexts {
+				contexts = append(contexts, dockerComposeContextItem{context, context.Name == config.Config.DockerCompose.CurrentContext})
 			}
+			return dockerComposeContextPrinter.Print(os.Stdout, contexts)
 		},
 	}
 	listCommand.Flags().BoolVarP(&verbose, "verbose", "v", false, "Display verbose contexts")
+	listCommand.Flags().MarkDeprecated("verbose", "use --output wide instead")
 	command.AddCommand(listCommand)
 
 	deleteCommand := &cobra.Command{
@@ -107,22 +98,9 @@ func buildDockerComposeContextCommand() *cobra.Command {
 		Short: "Delete a docker compose context",
 		Long:  "Delete a docker compose context from devcore without deleting the actual files",
 		Args:  cobra.MaximumNArgs(1),
-		PreRunE: func(cmd *cobra.Command, args []string) error {
-			contextToDelete := ""
-			if len(args) == 1 {
-				contextToDelete = args[0]
-			} else if config.Config.DockerCompose.CurrentContext != "" {
-				contextToDelete = config.Config.DockerCompose.CurrentContext
-			} else {
-				return errors.New("No docker compose context specified, neither a current one is set")
-			}
-
-			if c, err := config.Config.DockerCompose.FindContextByName(contextToDelete); config.IsDockerComposeContextNotFound(err) {
-				return err
-			} else {
-				context = c
-				return nil
-			}
+		PreRunE: func(cmd *cobra.Command, args []string) (err error) {
+			context, err = findDockerComposeContext(args)
+			return err
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			config.Config.DockerCompose.DeleteContext(context)
@@ -162,95 +140,123 @@ func buildDockerComposeContextCommand() *cobra.Command {
 	command.AddCommand(setCurrentCommand)
 
 	startCommand := &cobra.Command{
-		Use:   "start",
+		Use:   "start [context]",
 		Short: "Starts a docker compose context",
 		Args:  cobra.MaximumNArgs(1),
-		PreRunE: func(cmd *cobra.Command, args []string) error {
-			contextToStart := ""
-			if len(args) == 1 {
-				contextToStart = args[0]
-			} else if config.Config.DockerCompose.CurrentContext != "" {
-				contextToStart = config.Config.DockerCompose.CurrentContext
-			} else {
-				return errors.New("No docker compose context specified, neither a current one is set")
-			}
-
-			if c, err := config.Config.DockerCompose.FindContextByName(contextToStart); config.IsDockerComposeContextNotFound(err) {
-				return err
-			} else {
-				context = c
-				return nil
-			}
+		PreRunE: func(cmd *cobra.Command, args []string) (err error) {
+			context, err = findDockerComposeContext(args)
+			return err
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			fmt.Println(fmt.Sprintf("Starting context '%s'", context.Name))
-			out, err := pkg.ExecCommand("docker", "compose", "-f", context.File, "up", "-d")
-			if verbose {
-				fmt.Println(out)
-			}
-			return err
+			return dockerCompose(cmd.Context(), verbose, "-f", context.File, "up", "-d")
 		},
 	}
 	startCommand.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose mode")
 	command.AddCommand(startCommand)
 
 	stopCommand := &cobra.Command{
-		Use:   "stop",
+		Use:   "stop [context]",
 		Short: "Stops a docker compose context",
+		Long: `Stops a docker compose context according to its stop policy: its containers are stopped, keeping their state,
+or removed when the policy is down. Volumes are always kept.`,
+		Args: cobra.MaximumNArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) (err error) {
+			context, err = findDockerComposeContext(args)
+			return err
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fmt.Println(fmt.Sprintf("Stopping context '%s'", context.Name))
+			return dockerCompose(cmd.Context(), verbose, "-f", context.File, context.StopVerb())
+		},
+	}
+	stopCommand.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose mode")
+	command.AddCommand(stopCommand)
+
+	downCommand := &cobra.Command{
+		Use:   "down [context]",
+		Short: "Removes the containers of a docker compose context, keeping its volumes",
 		Args:  cobra.MaximumNArgs(1),
-		PreRunE: func(cmd *cobra.Command, args []string) error {
-			contextToStop := ""
-			if len(args) == 1 {
-				contextToStop = args[0]
-			} else if config.Config.DockerCompose.CurrentContext != "" {
-				contextToStop = config.Config.DockerCompose.CurrentContext
-			} else {
-				return errors.New("No docker compose context specified, neither a current one is set")
+		PreRunE: func(cmd *cobra.Command, args []string) (err error) {
+			context, err = findDockerComposeContext(args)
+			return err
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fmt.Println(fmt.Sprintf("Removing the containers of context '%s'", context.Name))
+			return dockerCompose(cmd.Context(), verbose, "-f", context.File, "down")
+		},
+	}
+	downCommand.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose mode")
+	command.AddCommand(downCommand)
+
+	destroyConfirmed := false
+	destroyCommand := &cobra.Command{
+		Use:   "destroy [context]",
+		Short: "Removes the containers and the volumes of a docker compose context",
+		Long: `Removes the containers and the volumes of a docker compose context, after a confirmation. The destruction
+fails when it is not confirmed, and --yes is required when the standard input is not a terminal.`,
+		Args: cobra.MaximumNArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) (err error) {
+			context, err = findDockerComposeContext(args)
+			if err != nil || destroyConfirmed || pkg.DryRun {
+				return err
 			}
 
-			if c, err := config.Config.DockerCompose.FindContextByName(contextToStop); config.IsDockerComposeContextNotFound(err) {
+			if stdin, isFile := cmd.InOrStdin().(*os.File); isFile && !pkg.IsTerminal(stdin) {
+				return errors.New(fmt.Sprintf("Can not confirm the destruction of the context '%s' without a terminal, use --yes", context.Name))
+			}
+			fmt.Printf("Are you sure you want to destroy the context '%s', deleting the data of its volumes? [yN] ", context.Name)
+			answer, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+			if err != nil && err != io.EOF {
 				return err
-			} else {
-				context = c
-				return nil
 			}
+			answer = strings.TrimSpace(answer)
+			if answer != "y" && answer != "Y" {
+				return errors.New(fmt.Sprintf("Destruction of the context '%s' aborted", context.Name))
+			}
+			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Println(fmt.Sprintf("Stopping context '%s'", context.Name))
-			out, err := pkg.ExecCommand("docker", "compose", "-f", context.File, "down", "-v")
-			if verbose {
-				fmt.Println(out)
-			}
+			fmt.Println(fmt.Sprintf("Destroying context '%s'", context.Name))
+			return dockerCompose(cmd.Context(), verbose, "-f", context.File, "down", "--volumes")
+		},
+	}
+	destroyCommand.Flags().BoolVarP(&destroyConfirmed, "yes", "y", false, "Destroy the context without asking for a confirmation")
+	destroyCommand.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose mode")
+	command.AddCommand(destroyCommand)
+
+	restartCommand := &cobra.Command{
+		Use:   "restart [context] [-- service...]",
+		Short: "Restarts the services of a docker compose context, all of them when none is given",
+		Long: `Restarts the services of a docker compose context, the current one when none is given. The services follow
+a --, e.g. 'devcore docker-compose context restart backend -- api db'.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			return cobra.MaximumNArgs(1)(cmd, contextArgs(cmd, args))
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) (err error) {
+			context, err = findDockerComposeContext(contextArgs(cmd, args))
 			return err
 		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			services := args[len(contextArgs(cmd, args)):]
+			fmt.Println(fmt.Sprintf("Restarting context '%s'", context.Name))
+			return dockerCompose(cmd.Context(), verbose, append([]string{"-f", context.File, "restart"}, services...)...)
+		},
 	}
-	stopCommand.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose mode")
-	command.AddCommand(stopCommand)
+	restartCommand.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose mode")
+	command.AddCommand(restartCommand)
 
 	openFolderCmd := &cobra.Command{
 		Use:   "open-folder",
 		Short: "Open the file explorer at the docker compose context",
 		Args:  cobra.MaximumNArgs(1),
-		PreRunE: func(cmd *cobra.Command, args []string) error {
-			contextToStop := ""
-			if len(args) == 1 {
-				contextToStop = args[0]
-			} else if config.Config.DockerCompose.CurrentContext != "" {
-				contextToStop = config.Config.DockerCompose.CurrentContext
-			} else {
-				return errors.New("No docker compose context specified, neither a current one is set")
-			}
-
-			if c, err := config.Config.DockerCompose.FindContextByName(contextToStop); config.IsDockerComposeContextNotFound(err) {
-				return err
-			} else {
-				context = c
-				return nil
-			}
+		PreRunE: func(cmd *cobra.Command, args []string) (err error) {
+			context, err = findDockerComposeContext(args)
+			return err
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if runtime.GOOS == "darwin" {
-				_, err := pkg.ExecCommand("open", context.Dir())
+				_, err := runner.Run(cmd.Context(), pkg.Command{Name: "open", Args: []string{context.Dir()}})
 				return err
 			} else {
 				return errors.New(fmt.Sprintf("%s not supported for this action", runtime.GOOS))
@@ -261,3 +267,60 @@ func buildDockerComposeContextCommand() *cobra.Command {
 
 	return command
 }
+
+// findDockerComposeContext returns the context named by the arguments, the current one when they are empty.
+func findDockerComposeContext(args []string) (config.DockerComposeContext, error) {
+	name := config.Config.DockerCompose.CurrentContext
+	if len(args) == 1 {
+		name = args[0]
+	} else if name == "" {
+		return config.DockerComposeContext{}, errors.New("No docker compose context specified, neither a current one is set")
+	}
+	return config.Config.DockerCompose.FindContextByName(name)
+}
+
+// contextArgs returns the arguments preceding --, naming the context, the following ones being given to docker compose.
+func contextArgs(cmd *cobra.Command, args []string) []string {
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		return args[:dash]
+	}
+	return args
+}
+
+// dockerCompose runs docker compose with the arguments. Its output is only shown in verbose mode or when it fails.
+func dockerCompose(ctx gocontext.Context, verbose bool, args ...string) error {
+	command := pkg.Command{Name: "docker", Args: append([]string{"compose"}, args...)}
+	if verbose {
+		_, err := runner.Run(ctx, command)
+		return err
+	}
+	_, err := runner.Output(ctx, command)
+	return err
+}
+
+// dockerComposeContextItem is a docker compose context as listed.
+type dockerComposeContextItem struct {
+	config.DockerComposeContext
+	Current bool `json:"current"`
+}
+
+var dockerComposeContextPrinter = output.Printer{
+	Columns: []output.Column{
+		{Header: "CURRENT", Value: func(item interface{}) string {
+			return currentMarker(item.(dockerComposeContextItem).Current)
+		}},
+		{Header: "NAME", Value: func(item interface{}) string {
+			return item.(dockerComposeContextItem).Name
+		}},
+		{Header: "DESCRIPTION", Value: func(item interface{}) string {
+			return item.(dockerComposeContextItem).Description
+		}},
+		{Header: "FILE", Wide: true, Value: func(item interface{}) string {
+			return item.(dockerComposeContextItem).File
+		}},
+		{Header: "STOP POLICY", Wide: true, Value: func(item interface{}) string {
+			context := item.(dockerComposeContextItem)
+			return context.StopVerb()
+		}},
+	},
+}
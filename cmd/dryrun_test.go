@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"io.twasyl/devcore/pkg/config"
+	du "io.twasyl/devcore/pkg/utils"
+)
+
+// runDryRun executes devcore in dry run mode, with the actual runner, and returns the planned steps.
+func runDryRun(t *testing.T, args ...string) ([]string, error) {
+	output := &bytes.Buffer{}
+	du.DryRunOutput = output
+	t.Cleanup(func() {
+		du.DryRun = false
+		du.DryRunOutput = os.Stdout
+	})
+
+	rootCmd.SetArgs(append([]string{"--dry-run"}, args...))
+	err := rootCmd.Execute()
+	return strings.Split(strings.TrimSpace(output.String()), "\n"), err
+}
+
+func TestDryRunCommands(t *testing.T) {
+	steps, err := runDryRun(t, "helm", "install", "prometheus", "prometheus-community/prometheus", "-n", "monitoring")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"[dry-run] run kubectl create namespace monitoring",
+		"[dry-run] run helm install prometheus prometheus-community/prometheus -n monitoring",
+	}
+	if strings.Join(steps, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected the steps %q, got %q", expected, steps)
+	}
+}
+
+func TestDryRunToolUninstall(t *testing.T) {
+	prefix := t.TempDir()
+	versionDir := filepath.Join(prefix, "tools", "kind", "0.12.0")
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("0.12.0", filepath.Join(prefix, "tools", "kind", "current")); err != nil {
+		t.Fatal(err)
+	}
+	previous := config.Config.InstallPrefix
+	config.Config.InstallPrefix = prefix
+	defer func() {
+		config.Config.InstallPrefix = previous
+	}()
+
+	steps, err := runDryRun(t, "tools", "uninstall", "kind", "0.12.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(steps) != 3 || steps[2] != "[dry-run] remove "+versionDir {
+		t.Errorf("Expected the links and %s to be removed, got %q", versionDir, steps)
+	}
+	if _, err := os.Stat(versionDir); err != nil {
+		t.Errorf("Expected %s to be kept: %s", versionDir, err)
+	}
+}
+
+func TestDryRunForeignPlatformInstall(t *testing.T) {
+	prefix := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(prefix, "tools", "kind", "0.12.0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	previous := config.Config.InstallPrefix
+	config.Config.InstallPrefix = prefix
+	defer func() {
+		config.Config.InstallPrefix = previous
+		toolVersion = ""
+		toolArch = runtime.GOARCH
+	}()
+
+	arch := "arm64"
+	if runtime.GOARCH == arch {
+		arch = "amd64"
+	}
+	steps, err := runDryRun(t, "tools", "install", "kind", "-v", "0.12.0", "--arch", arch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	versionDir := filepath.Join(prefix, "tools", "kind", ".platforms", runtime.GOOS+"-"+arch, "0.12.0")
+	if len(steps) == 0 || !strings.HasSuffix(steps[len(steps)-1], " to "+filepath.Join(versionDir, "kind")) {
+		t.Errorf("Expected the build for %s to be installed in %s, got %q", arch, versionDir, steps)
+	}
+	for _, step := range steps {
+		if strings.HasPrefix(step, "[dry-run] link ") {
+			t.Errorf("Expected the build for %s not to be linked, got %q", arch, step)
+		}
+	}
+}
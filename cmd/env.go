@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"io.twasyl/devcore/pkg/config"
+)
+
+var supportedShells = []string{"bash", "zsh", "fish"}
+
+func init() {
+	rootCmd.AddCommand(buildEnvCommand())
+	rootCmd.AddCommand(buildHookCommand())
+}
+
+func buildEnvCommand() *cobra.Command {
+	var shell string
+
+	command := &cobra.Command{
+		Use:   "env",
+		Short: "Print the commands setting up the shell environment for devcore",
+		Long: "Print the commands setting up the shell environment: the PATH giving access to the installed tools, the " +
+			"versions pinned by the current project first, the homes of the tools like MAVEN_HOME and the variables " +
+			"of the current Jenkins and docker compose contexts. Use it with eval \"$(devcore env)\".",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			shell, err := resolveShell(shell)
+			if err != nil {
+				return err
+			}
+
+			dir, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+
+			variables, err := config.Environment(dir)
+			if err != nil {
+				return err
+			}
+			for _, variable := range variables {
+				fmt.Println(shellStatement(shell, variable))
+			}
+			return nil
+		},
+	}
+	addShellFlag(command, &shell)
+
+	return command
+}
+
+func buildHookCommand() *cobra.Command {
+	var shell string
+
+	command := &cobra.Command{
+		Use:   "hook",
+		Short: "Print the shell code evaluating devcore env each time the current directory changes",
+		Long: "Print the shell code evaluating devcore env each time the current directory changes, so that the " +
+			"versions pinned by a project are used when entering it. Add eval \"$(devcore hook)\" to the shell's rc " +
+			"file, or devcore hook --shell fish | source for fish.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			shell, err := resolveShell(shell)
+			if err != nil {
+				return err
+			}
+
+			executable, err := os.Executable()
+			if err != nil {
+				return err
+			}
+
+			fmt.Print(shellHook(shell, quote(shell, executable)))
+			return nil
+		},
+	}
+	addShellFlag(command, &shell)
+
+	return command
+}
+
+func addShellFlag(command *cobra.Command, shell *string) {
+	command.Flags().StringVarP(shell, "shell", "s", "", fmt.Sprintf("The shell, one of %s. Detected from $SHELL by default", strings.Join(supportedShells, ", ")))
+	command.RegisterFlagCompletionFunc("shell", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return supportedShells, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// resolveShell checks the given shell is supported, detecting the user's shell when none is given.
+func resolveShell(shell string) (string, error) {
+	if shell == "" {
+		shell = filepath.Base(os.Getenv("SHELL"))
+		for _, supported := range supportedShells {
+			if shell == supported {
+				return shell, nil
+			}
+		}
+		return "bash", nil
+	}
+
+	for _, supported := range supportedShells {
+		if shell == supported {
+			return shell, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported shell %s, expecting one of %s", shell, strings.Join(supportedShells, ", "))
+}
+
+func shellStatement(shell string, variable config.EnvVariable) string {
+	if shell == "fish" {
+		if variable.Unset {
+			return fmt.Sprintf("set -e %s;", variable.Name)
+		}
+		if variable.Name == "PATH" {
+			dirs := []string{}
+			for _, dir := range filepath.SplitList(variable.Value) {
+				dirs = append(dirs, quote(shell, dir))
+			}
+			return fmt.Sprintf("set -gx PATH %s;", strings.Join(dirs, " "))
+		}
+		return fmt.Sprintf("set -gx %s %s;", variable.Name, quote(shell, variable.Value))
+	}
+
+	if variable.Unset {
+		return fmt.Sprintf("unset %s;", variable.Name)
+	}
+	return fmt.Sprintf("export %s=%s;", variable.Name, quote(shell, variable.Value))
+}
+
+// quote quotes the value so that the shell takes it literally.
+func quote(shell string, value string) string {
+	if shell == "fish" {
+		return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(value) + "'"
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func shellHook(shell string, executable string) string {
+	switch shell {
+	case "zsh":
+		return fmt.Sprintf(`_devcore_hook() {
+  eval "$(%s env --shell zsh)"
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook chpwd _devcore_hook
+_devcore_hook
+`, executable)
+	case "fish":
+		return fmt.Sprintf(`function __devcore_hook --on-variable PWD
+  %s env --shell fish | source
+end
+__devcore_hook
+`, executable)
+	default:
+		return fmt.Sprintf(`_devcore_hook() {
+  local previous_exit_status=$?
+  if [[ "$PWD" != "${_DEVCORE_DIR:-}" ]]; then
+    _DEVCORE_DIR="$PWD"
+    eval "$(%s env --shell bash)"
+  fi
+  return $previous_exit_status
+}
+if [[ ";${PROMPT_COMMAND:-};" != *";_devcore_hook;"* ]]; then
+  PROMPT_COMMAND="_devcore_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`, executable)
+	}
+}
@@ -1,8 +1,7 @@
 package cmd
 
 import (
-	"fmt"
-	"log"
+	"strings"
 
 	"github.com/spf13/cobra"
 	du "io.twasyl/devcore/pkg/utils"
@@ -33,9 +32,8 @@ var helmInstallCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(2),
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		if helmNamespace != "" {
-			output, err := du.ExecCommand("kubectl", "create", "namespace", helmNamespace)
-			if err != nil {
-				log.Fatal(output)
+			result, err := runner.Output(cmd.Context(), kubectl("create", "namespace", helmNamespace))
+			if err != nil && !strings.Contains(result.Stderr, "AlreadyExists") {
 				return err
 			}
 		}
@@ -46,12 +44,8 @@ var helmInstallCmd = &cobra.Command{
 		if helmNamespace != "" {
 			args = append(args, "-n", helmNamespace)
 		}
-		output, err := du.ExecCommand("helm", args...)
-		fmt.Print(output)
-		if err != nil {
-			return err
-		}
-		return nil
+		_, err := runner.Run(cmd.Context(), du.Command{Name: "helm", Args: args})
+		return err
 	},
 }
 
@@ -64,11 +58,7 @@ var helmUninstallCmd = &cobra.Command{
 		if helmNamespace != "" {
 			args = append(args, "-n", helmNamespace)
 		}
-		output, err := du.ExecCommand("helm", args...)
-		fmt.Print(output)
-		if err != nil {
-			return err
-		}
-		return nil
+		_, err := runner.Run(cmd.Context(), du.Command{Name: "helm", Args: args})
+		return err
 	},
 }
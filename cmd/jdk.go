@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/spf13/cobra"
+	"io.twasyl/devcore/pkg/config"
+	"io.twasyl/devcore/pkg/output"
+	du "io.twasyl/devcore/pkg/utils"
+)
+
+func init() {
+	rootCmd.AddCommand(buildJdkCommand())
+}
+
+func buildJdkCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "jdk",
+		Short: "Install and switch between Eclipse Temurin JDKs",
+		Long: "Install and switch between Eclipse Temurin JDKs. Versions are given either as a feature version, like 17, " +
+			"or as an exact version like 17.0.8+7.",
+	}
+	command.PersistentFlags().BoolVar(&config.Offline, "offline", false, "Don't download anything, only use the download cache")
+
+	command.AddCommand(buildJdkInstallCommand())
+	command.AddCommand(buildJdkListCommand())
+	command.AddCommand(buildJdkUseCommand())
+	command.AddCommand(buildJdkHomeCommand())
+
+	return command
+}
+
+func buildJdkInstallCommand() *cobra.Command {
+	var arch string
+
+	command := &cobra.Command{
+		Use:   "install <version>",
+		Short: "Install the latest release of a JDK feature version, or an exact version",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			platform := config.Platform{OS: runtime.GOOS, Arch: arch}
+			release, err := config.Config.JDK.FindJDKRelease(args[0], platform)
+			if err != nil {
+				return err
+			}
+
+			tool := release.Tool()
+			if tool.IsInstalled(release.Version, platform) {
+				if platform != config.CurrentPlatform() {
+					fmt.Println(fmt.Sprintf("JDK %s for %s is already installed in %s", release.Version, platform, tool.PlatformVersionDir(release.Version, platform)))
+					return nil
+				}
+				fmt.Println(fmt.Sprintf("JDK %s is already installed, using it", release.Version))
+				return tool.Use(release.Version)
+			}
+
+			if err := installTool(tool, release.Version, platform); err != nil {
+				return err
+			}
+			if !du.DryRun && platform == config.CurrentPlatform() {
+				fmt.Println(fmt.Sprintf("JDK %s installed in %s", release.Version, tool.VersionDir(release.Version)))
+				warnIfBinDirNotInPath()
+			}
+			return nil
+		},
+	}
+	command.Flags().StringVar(&arch, "arch", runtime.GOARCH, "The architecture to install the JDK for")
+
+	return command
+}
+
+func buildJdkListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the installed JDKs",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			versions, err := installedVersions(config.JDKTool())
+			if err != nil {
+				return err
+			}
+
+			if len(versions) == 0 && output.Selected.IsTable() {
+				fmt.Println("No JDK installed")
+				return nil
+			}
+			return installedVersionPrinter.Print(os.Stdout, versions)
+		},
+	}
+}
+
+func buildJdkUseCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "use <version>",
+		Short: "Make an installed JDK the one linked in the bin directory",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			version, err := config.InstalledJDK(args[0])
+			if err != nil {
+				return err
+			}
+
+			tool := config.JDKTool()
+			if err := tool.Use(version); err != nil {
+				return err
+			}
+			fmt.Println(fmt.Sprintf("Now using JDK %s", version))
+			return nil
+		},
+	}
+}
+
+func buildJdkHomeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "home [version]",
+		Short: "Print the home directory of an installed JDK, the active one by default",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tool := config.JDKTool()
+
+			version := tool.ActiveVersion()
+			if len(args) > 0 {
+				var err error
+				if version, err = config.InstalledJDK(args[0]); err != nil {
+					return err
+				}
+			} else if version == "" {
+				return fmt.Errorf("no JDK in use, see devcore jdk use")
+			}
+
+			fmt.Println(tool.VersionDir(version))
+			return nil
+		},
+	}
+}
@@ -4,14 +4,14 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
 	"syscall"
 
 	"github.com/spf13/cobra"
 	"io.twasyl/devcore/pkg/config"
+	"io.twasyl/devcore/pkg/output"
 	du "io.twasyl/devcore/pkg/utils"
-	pkg "io.twasyl/devcore/pkg/utils"
 )
 
 func init() {
@@ -87,8 +87,7 @@ func buildJenkinsCliCommand() *cobra.Command {
 			}
 
 			cliArgs = append(cliArgs, args...)
-			output, err := du.ExecCommand("java", cliArgs...)
-			fmt.Println(output)
+			_, err := runner.Run(cmd.Context(), du.Command{Name: "java", Args: cliArgs, Stdin: os.Stdin})
 			return err
 		},
 	}
@@ -102,6 +101,7 @@ func buildJenkinsCliCommand() *cobra.Command {
 func buildJenkinsContextCommand() *cobra.Command {
 	context := config.JenkinsContext{}
 	context.Pid = -1
+	var java string
 
 	var command = &cobra.Command{
 		Use:     "context",
@@ -122,6 +122,21 @@ func buildJenkinsContextCommand() *cobra.Command {
 				return errors.New(fmt.Sprintf("A Jenkins context named '%s' already exists", context.Name))
 			}
 
+			if java != "" {
+				if context.JavaHome != "" {
+					return errors.New("Only one of --java and --java-home can be used")
+				}
+
+				version, err := config.InstalledJDK(java)
+				if config.IsToolVersionNotInstalled(err) {
+					return errors.New(fmt.Sprintf("No JDK %s installed. Use 'devcore jdk install %s' first", java, java))
+				} else if err != nil {
+					return err
+				}
+				jdk := config.JDKTool()
+				context.JavaHome = jdk.VersionDir(version)
+			}
+
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -135,6 +150,7 @@ func buildJenkinsContextCommand() *cobra.Command {
 	createCommand.Flags().StringVarP(&context.War, "war", "w", "", "The Jenkins war to use")
 	createCommand.Flags().StringVar(&context.JenkinsHome, "jenkins-home", "", "The folder to use as Jenkins home")
 	createCommand.Flags().StringVar(&context.JavaHome, "java-home", "", "The Java home to use with this context. If unspecified, the default JAVA_HOME of the system will be used")
+	createCommand.Flags().StringVar(&java, "java", "", "The version of the JDK installed by devcore to use with this context, like 17")
 	createCommand.Flags().StringArrayVar(&context.Options, "option", nil, "The option to pass to Jenkins at startup. Use multiple times for multiple options")
 	createCommand.Flags().StringArrayVar(&context.JVMOptions, "jvm-option", nil, "The JVM option to pass to Jenkins at startup. Use multiple times for multiple options")
 	createCommand.MarkFlagRequired("name")
@@ -146,39 +162,20 @@ func buildJenkinsContextCommand() *cobra.Command {
 		Use:     "list",
 		Aliases: []string{"ls"},
 		Short:   "Lists existing Jenkins contexts",
-		Run: func(cmd *cobra.Command, args []string) {
-			for index, context := range config.Config.Jenkins.Contexts {
-				if verbose {
-					if index == 0 {
-						fmt.Println("")
-					}
-					if context.Name == config.Config.Jenkins.CurrentContext {
-						fmt.Println(fmt.Sprintf("* Name: %s", context.Name))
-					} else {
-						fmt.Println(fmt.Sprintf("  Name: %s", context.Name))
-					}
-					fmt.Println(fmt.Sprintf("  Description: %s", context.Description))
-					fmt.Println(fmt.Sprintf("  War: %s", context.War))
-					fmt.Println(fmt.Sprintf("  Jenkins home: %s", context.JenkinsHome))
-					fmt.Println(fmt.Sprintf("  Java home: %s", context.JavaHome))
-					fmt.Println(fmt.Sprintf("  Options: %s", context.Options))
-					fmt.Println(fmt.Sprintf("  PID: %d", context.Pid))
-
-					if index < len(config.Config.Jenkins.Contexts)-1 {
-						fmt.Println("")
-						fmt.Println("")
-					}
-				} else {
-					if context.Name == config.Config.Jenkins.CurrentContext {
-						fmt.Println(fmt.Sprintf("* %s", context.Name))
-					} else {
-						fmt.Println(fmt.Sprintf("  %s", context.Name))
-					}
-				}
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if verbose {
+				output.Selected = output.Format{Name: output.Wide}
 			}
+
+			contexts := make([]jenkinsContextItem, 0)
+			for _, context := range config.Config.Jenkins.Contexts {
+				contexts = append(contexts, jenkinsContextItem{context, context.Name == config.Config.Jenkins.CurrentContext})
+			}
+			return jenkinsContextPrinter.Print(os.Stdout, contexts)
 		},
 	}
 	listCommand.Flags().BoolVarP(&verbose, "verbose", "v", false, "Display verbose contexts")
+	listCommand.Flags().MarkDeprecated("verbose", "use --output wide instead")
 	command.AddCommand(listCommand)
 
 	deleteCommand := &cobra.Command{
@@ -270,21 +267,22 @@ func buildJenkinsContextCommand() *cobra.Command {
 				cmdArgs = append(cmdArgs, context.Options...)
 			}
 
-			c := exec.Command("java", cmdArgs...)
-
+			command := du.Command{Name: "java", Args: cmdArgs}
 			if context.JavaHome != "" {
-				c.Env = append(c.Env, fmt.Sprintf("JAVA_HOME=%s", context.JavaHome))
+				command.Name = filepath.Join(context.JavaHome, "bin", "java")
+				command.Env = append(command.Env, fmt.Sprintf("JAVA_HOME=%s", context.JavaHome))
 			}
 			if context.JenkinsHome != "" {
-				c.Env = append(c.Env, fmt.Sprintf("JENKINS_HOME=%s", context.JenkinsHome))
+				command.Env = append(command.Env, fmt.Sprintf("JENKINS_HOME=%s", context.JenkinsHome))
 			}
-			c.Env = append(c.Env, "JENKINS_HA=false")
+			command.Env = append(command.Env, "JENKINS_HA=false")
 
-			err := pkg.DisplayCommandOutput(c)
-			context.Pid = c.Process.Pid
-			config.Config.Jenkins.UpdateContext(context)
-			config.Save()
-			return err
+			pid, err := runner.Start(command)
+			if err != nil {
+				return err
+			}
+			context.Pid = pid
+			return config.Config.Jenkins.UpdateContext(context)
 		},
 	}
 	startCommand.Flags().StringArrayVar(&additionalJvmOptions, "jvm-option", nil, "The JVM option to pass when starting Jenkins. Use this option multiple times for many options")
@@ -364,3 +362,44 @@ func buildJenkinsContextCommand() *cobra.Command {
 
 	return command
 }
+
+// jenkinsContextItem is a Jenkins context as listed.
+type jenkinsContextItem struct {
+	config.JenkinsContext
+	Current bool `json:"current"`
+}
+
+var jenkinsContextPrinter = output.Printer{
+	Columns: []output.Column{
+		{Header: "CURRENT", Value: func(item interface{}) string {
+			return currentMarker(item.(jenkinsContextItem).Current)
+		}},
+		{Header: "NAME", Value: func(item interface{}) string {
+			return item.(jenkinsContextItem).Name
+		}},
+		{Header: "DESCRIPTION", Value: func(item interface{}) string {
+			return item.(jenkinsContextItem).Description
+		}},
+		{Header: "PID", Value: func(item interface{}) string {
+			if pid := item.(jenkinsContextItem).Pid; pid != 0 {
+				return fmt.Sprint(pid)
+			}
+			return ""
+		}},
+		{Header: "WAR", Wide: true, Value: func(item interface{}) string {
+			return item.(jenkinsContextItem).War
+		}},
+		{Header: "JENKINS HOME", Wide: true, Value: func(item interface{}) string {
+			return item.(jenkinsContextItem).JenkinsHome
+		}},
+		{Header: "JAVA HOME", Wide: true, Value: func(item interface{}) string {
+			return item.(jenkinsContextItem).JavaHome
+		}},
+		{Header: "OPTIONS", Wide: true, Value: func(item interface{}) string {
+			return strings.Join(item.(jenkinsContextItem).Options, " ")
+		}},
+		{Header: "JVM OPTIONS", Wide: true, Value: func(item interface{}) string {
+			return strings.Join(item.(jenkinsContextItem).JVMOptions, " ")
+		}},
+	},
+}
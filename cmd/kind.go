@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	du "io.twasyl/devcore/pkg/utils"
@@ -42,10 +46,10 @@ var createKindClusterCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Create a kind cluster",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := createKindCluster(); err != nil {
+		if err := createKindCluster(cmd.Context()); err != nil {
 			return err
 		}
-		return installK8sDashboard()
+		return installK8sDashboard(cmd.Context())
 	},
 }
 
@@ -53,10 +57,7 @@ var deleteKindClusterCmd = &cobra.Command{
 	Use:   "delete",
 	Short: "Delete a kind cluster",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		output, err := du.ExecCommand("kind", "delete", "cluster", "--name", kindClusterName)
-		if err != nil {
-			fmt.Println(output)
-		}
+		_, err := runner.Run(cmd.Context(), du.Command{Name: "kind", Args: []string{"delete", "cluster", "--name", kindClusterName}})
 		return err
 	},
 }
@@ -65,39 +66,64 @@ var kindTokenCmd = &cobra.Command{
 	Use:   "token",
 	Short: "Get an authentication token to be used in the K8s dashboard",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		output, err := du.ExecCommand("kubectl", "-n", "kubernetes-dashboard", "get", "sa/admin-user", "-o", "jsonpath=\"{.secrets[0].name}\"")
+		result, err := runner.Output(cmd.Context(), kubectl("-n", "kubernetes-dashboard", "get", "sa/admin-user", "-o", "jsonpath={.secrets[0].name}"))
 		if err != nil {
-			fmt.Print(output)
 			return err
 		}
-		secret := strings.ReplaceAll(output, "\"", "")
-		output, err = du.ExecCommand("kubectl", "-n", "kubernetes-dashboard", "get", "secret", secret, "-o", "go-template=\"{{.data.token | base64decode}}\"")
+		secret := strings.TrimSpace(result.Stdout)
+		if secret == "" {
+			return errors.New("No secret found for the admin-user service account of the dashboard")
+		}
+
+		result, err = runner.Output(cmd.Context(), kubectl("-n", "kubernetes-dashboard", "get", "secret", secret, "-o", "go-template={{.data.token | base64decode}}"))
 		if err != nil {
-			fmt.Print(output)
 			return err
 		}
+		token := strings.TrimSpace(result.Stdout)
+		if token == "" {
+			return errors.New(fmt.Sprintf("The secret %s holds no token", secret))
+		}
 
-		du.ToClipboard([]byte(strings.ReplaceAll(output, "\"", "")))
-		fmt.Println("Token copied to your clipboard.")
-		du.OpenBrowser("http://localhost:8001/api/v1/namespaces/kubernetes-dashboard/services/https:kubernetes-dashboard:/proxy/")
-		return nil
+		if err := du.ToClipboard(runner, []byte(token)); err != nil {
+			fmt.Fprintf(os.Stderr, "Can not copy the token to your clipboard (%s), here it is:\n", err)
+			fmt.Println(token)
+		} else {
+			fmt.Println("Token copied to your clipboard.")
+		}
+		return du.OpenBrowser(runner, "http://localhost:8001/api/v1/namespaces/kubernetes-dashboard/services/https:kubernetes-dashboard:/proxy/")
 	},
 }
 
-func createKindCluster() error {
-	output, err := du.ExecCommand("kind", "create", "cluster", "--name", kindClusterName)
-	fmt.Print(output)
+// kubectl returns the kubectl command with the given arguments, which is expected to answer quickly.
+func kubectl(args ...string) du.Command {
+	return du.Command{Name: "kubectl", Args: args, Timeout: time.Minute}
+}
+
+func createKindCluster(ctx context.Context) error {
+	_, err := runner.Run(ctx, du.Command{Name: "kind", Args: []string{"create", "cluster", "--name", kindClusterName}})
 	return err
 }
 
-func installK8sDashboard() error {
-	_, err := du.ExecCommand("kubectl", "apply", "-f", fmt.Sprintf("https://raw.githubusercontent.com/kubernetes/dashboard/%s/aio/deploy/recommended.yaml", k8sDashboardVersion))
+func installK8sDashboard(ctx context.Context) error {
+	// Downloaded by devcore rather than kubectl for the network settings, like mirrors, to apply
+	dashboardFile, err := os.CreateTemp("", "devcore-dashboard-*.yaml")
 	if err != nil {
 		return err
 	}
+	dashboardFile.Close()
+	defer os.Remove(dashboardFile.Name())
 
-	configFile := "./tmp.yaml"
-	configFileContent := []byte(`apiVersion: v1
+	err = du.DownloadFile(fmt.Sprintf("https://raw.githubusercontent.com/kubernetes/dashboard/%s/aio/deploy/recommended.yaml", k8sDashboardVersion), dashboardFile.Name())
+	if err != nil {
+		return err
+	}
+
+	_, err = runner.Run(ctx, du.Command{Name: "kubectl", Args: []string{"apply", "-f", dashboardFile.Name()}})
+	if err != nil {
+		return err
+	}
+
+	adminUser := []byte(`apiVersion: v1
 kind: ServiceAccount
 metadata:
   name: admin-user
@@ -115,13 +141,6 @@ subjects:
 - kind: ServiceAccount
   name: admin-user
   namespace: kubernetes-dashboard`)
-	err = os.WriteFile(configFile, configFileContent, 0744)
-	if err != nil {
-		return err
-	}
-
-	_, err = du.ExecCommand("kubectl", "apply", "-f", configFile)
-	os.Remove(configFile)
-
+	_, err = runner.Run(ctx, du.Command{Name: "kubectl", Args: []string{"apply", "-f", "-"}, Stdin: bytes.NewReader(adminUser)})
 	return err
 }
@@ -42,7 +42,7 @@ func buildOpenCommand() *cobra.Command {
 				return errors.New(fmt.Sprintf("The resource to open does not exist: %s", resource))
 			}
 
-			_, err := pkg.ExecCommand("open", resource)
+			_, err := runner.Run(cmd.Context(), pkg.Command{Name: "open", Args: []string{resource}})
 			return err
 		},
 	}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"io.twasyl/devcore/pkg/config"
+	"io.twasyl/devcore/pkg/output"
+	du "io.twasyl/devcore/pkg/utils"
+)
+
+// pluginExit is returned when a plugin exits with a non-zero code, which devcore exits with too.
+type pluginExit struct {
+	code int
+}
+
+func (e *pluginExit) Error() string {
+	return fmt.Sprintf("plugin exited with code %d", e.code)
+}
+
+func init() {
+	rootCmd.AddCommand(buildPluginCommand())
+}
+
+func buildPluginCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "plugin",
+		Short: "Manage the plugins extending devcore",
+		Long: fmt.Sprintf("Plugins are executables named %s<name> found in %s or on the PATH. They are run by devcore <name>, "+
+			"receiving the remaining arguments as is.", config.PluginPrefix, config.PluginsDir()),
+	}
+
+	command.AddCommand(buildPluginListCommand())
+
+	return command
+}
+
+func buildPluginListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the plugins found",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plugins := config.FindPlugins()
+			if len(plugins) == 0 && output.Selected.IsTable() {
+				fmt.Println("No plugin found")
+				return nil
+			}
+
+			items := make([]pluginItem, 0)
+			found := make(map[string]string)
+			for _, plugin := range plugins {
+				note := ""
+				if path, shadowed := found[plugin.Name]; shadowed {
+					note = fmt.Sprintf("shadowed by %s", path)
+				} else if isBuiltinCommand(plugin.Name) {
+					note = "ignored, conflicts with a built-in command"
+				} else {
+					found[plugin.Name] = plugin.Path
+				}
+				items = append(items, pluginItem{plugin, note})
+			}
+			return pluginPrinter.Print(os.Stdout, items)
+		},
+	}
+}
+
+// isBuiltinCommand tells if the name is the one, or an alias, of a command of devcore.
+func isBuiltinCommand(name string) bool {
+	for _, command := range rootCmd.Commands() {
+		if command.Annotations["plugin"] == "" && (command.Name() == name || command.HasAlias(name)) {
+			return true
+		}
+	}
+	return name == "help" || name == "completion"
+}
+
+// registerPlugins adds a command running each plugin found. Plugins can not replace built-in commands.
+func registerPlugins() {
+	for _, plugin := range config.FindPlugins() {
+		if isBuiltinCommand(plugin.Name) {
+			continue
+		}
+		if command, _, err := rootCmd.Find([]string{plugin.Name}); err == nil && command != rootCmd {
+			// A plugin with the same name was found first
+			continue
+		}
+		rootCmd.AddCommand(buildPluginRunCommand(plugin))
+	}
+}
+
+func buildPluginRunCommand(plugin config.Plugin) *cobra.Command {
+	return &cobra.Command{
+		Use:                plugin.Name,
+		Short:              fmt.Sprintf("Run the %s plugin", plugin.Path),
+		Annotations:        map[string]string{"plugin": plugin.Path},
+		DisableFlagParsing: true,
+		SilenceErrors:      true,
+		SilenceUsage:       true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			command := du.Command{Name: plugin.Path, Args: args, Env: config.PluginEnvironment(), Stdin: os.Stdin}
+			_, err := runner.Run(cmd.Context(), command)
+			var exitError *du.ExitError
+			if errors.As(err, &exitError) {
+				return &pluginExit{code: exitError.ExitCode}
+			}
+			return err
+		},
+	}
+}
+
+// pluginItem is a plugin as listed, with a note telling why it is not used.
+type pluginItem struct {
+	config.Plugin
+	Note string `json:"note,omitempty"`
+}
+
+var pluginPrinter = output.Printer{
+	Columns: []output.Column{
+		{Header: "NAME", Value: func(item interface{}) string {
+			return item.(pluginItem).Name
+		}},
+		{Header: "PATH", Value: func(item interface{}) string {
+			return item.(pluginItem).Path
+		}},
+		{Header: "NOTE", Value: func(item interface{}) string {
+			return item.(pluginItem).Note
+		}},
+	},
+}
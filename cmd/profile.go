@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"io.twasyl/devcore/pkg/config"
+	"io.twasyl/devcore/pkg/output"
+)
+
+func init() {
+	rootCmd.AddCommand(buildProfileCommand())
+}
+
+func buildProfileCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage the profiles of the configuration",
+		Long: `Profiles overlay the configuration with the settings of a team or a customer: projects and servers directories,
+default tools versions, docker compose and Jenkins contexts. The current profile is used unless another one is given with
+--profile or DEVCORE_PROFILE.`,
+	}
+
+	command.AddCommand(buildProfileCreateCommand())
+	command.AddCommand(buildProfileListCommand())
+	command.AddCommand(buildProfileUseCommand())
+	command.AddCommand(buildProfileDeleteCommand())
+
+	return command
+}
+
+func buildProfileCreateCommand() *cobra.Command {
+	profile := config.Profile{}
+	use := false
+
+	command := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a profile",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profile.Name = args[0]
+			if _, err := config.Config.FindProfileByName(profile.Name); err == nil {
+				return errors.New(fmt.Sprintf("A profile named '%s' already exists", profile.Name))
+			}
+
+			config.Config.AddProfile(profile)
+			if use {
+				config.Config.CurrentProfile = profile.Name
+			}
+			err := config.Save()
+			if err == nil {
+				fmt.Println(fmt.Sprintf("Profile '%s' created", profile.Name))
+			}
+			return err
+		},
+	}
+
+	command.Flags().StringVarP(&profile.Description, "description", "d", "", "The description of the profile")
+	command.Flags().StringVar(&profile.ProjectsDir, "projects-dir", "", "The projects directory of the profile")
+	command.Flags().StringVar(&profile.ServersDir, "servers-dir", "", "The servers directory of the profile")
+	command.Flags().BoolVar(&use, "use", false, "Make the profile the current one")
+
+	return command
+}
+
+func buildProfileListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the profiles",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(config.Config.Profiles) == 0 && output.Selected.IsTable() {
+				fmt.Println("No profile found")
+				return nil
+			}
+
+			profiles := make([]profileItem, 0)
+			for _, profile := range config.Config.Profiles {
+				profiles = append(profiles, profileItem{profile, profile.Name == config.ActiveProfile()})
+			}
+			return profilePrinter.Print(os.Stdout, profiles)
+		},
+	}
+}
+
+func buildProfileUseCommand() *cobra.Command {
+	none := false
+
+	command := &cobra.Command{
+		Use:   "use <name>",
+		Short: "Set the current profile",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if none {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if none {
+				config.Config.CurrentProfile = ""
+				err := config.Save()
+				if err == nil {
+					fmt.Println("No current profile, the base configuration is used")
+				}
+				return err
+			}
+
+			profile, err := config.Config.FindProfileByName(args[0])
+			if err != nil {
+				return err
+			}
+			config.Config.CurrentProfile = profile.Name
+			err = config.Save()
+			if err == nil {
+				fmt.Println(fmt.Sprintf("Current profile set to '%s'", profile.Name))
+			}
+			return err
+		},
+	}
+
+	command.Flags().BoolVar(&none, "none", false, "Use the base configuration, without profile")
+
+	return command
+}
+
+func buildProfileDeleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "delete <name>",
+		Aliases: []string{"rm"},
+		Short:   "Delete a profile and its contexts",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profile, err := config.Config.FindProfileByName(args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := config.Config.DeleteProfile(profile); err != nil {
+				return err
+			}
+			err = config.Save()
+			if err == nil {
+				fmt.Println(fmt.Sprintf("Profile '%s' deleted", profile.Name))
+			}
+			return err
+		},
+	}
+}
+
+// profileItem is a profile as listed.
+type profileItem struct {
+	config.Profile
+	Active bool `json:"active"`
+}
+
+var profilePrinter = output.Printer{
+	Columns: []output.Column{
+		{Header: "ACTIVE", Value: func(item interface{}) string {
+			return currentMarker(item.(profileItem).Active)
+		}},
+		{Header: "NAME", Value: func(item interface{}) string {
+			return item.(profileItem).Name
+		}},
+		{Header: "DESCRIPTION", Value: func(item interface{}) string {
+			return item.(profileItem).Description
+		}},
+		{Header: "PROJECTS DIR", Wide: true, Value: func(item interface{}) string {
+			return item.(profileItem).ProjectsDir
+		}},
+		{Header: "SERVERS DIR", Wide: true, Value: func(item interface{}) string {
+			return item.(profileItem).ServersDir
+		}},
+	},
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path"
@@ -49,8 +51,7 @@ func buildProjectCloneCmd() *cobra.Command {
 		Short: "Clone a project from GitHub",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			out, err := pkg.ExecCommandInDir(config.Config.ProjectsDir, "git", "clone", fmt.Sprintf("git@github.com:%s.git", args[0]))
-			fmt.Println(out)
+			_, err := runner.Run(cmd.Context(), pkg.Command{Name: "git", Args: []string{"clone", fmt.Sprintf("git@github.com:%s.git", args[0])}, Dir: config.Config.ProjectsDir})
 			return err
 		},
 	}
@@ -68,22 +69,17 @@ func buildProjectUpdateCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			fmt.Printf("Updating project %s\n", args[0])
 			gitProjectDir := path.Join(config.Config.ProjectsDir, args[0])
-			out, err := pkg.ExecCommandInDir(gitProjectDir, "git", "pull")
-
-			if verbose {
-				fmt.Println(out)
-			}
-			if err != nil && !forceOnError {
-				return err
+			failures := 0
+			if err := gitPull(cmd.Context(), gitProjectDir, verbose); err != nil {
+				if !forceOnError {
+					return err
+				}
+				fmt.Fprintln(os.Stderr, err)
+				failures++
 			}
 
 			// Listing git subprojects and update them
-			dir, err := os.Open((gitProjectDir))
-			if err != nil {
-				return err
-			}
-
-			subProjects, err := dir.ReadDir(-1)
+			subProjects, err := os.ReadDir(gitProjectDir)
 			if err != nil {
 				return err
 			}
@@ -92,21 +88,35 @@ func buildProjectUpdateCmd() *cobra.Command {
 				if subProject.IsDir() {
 					if _, err := os.Stat(path.Join(gitProjectDir, subProject.Name(), ".git")); !os.IsNotExist(err) {
 						fmt.Printf("Updating subproject %s\n", subProject.Name())
-						out, err := pkg.ExecCommandInDir(path.Join(gitProjectDir, subProject.Name()), "git", "pull")
-
-						if verbose {
-							fmt.Println(out)
-						}
-						if err != nil && !forceOnError {
-							return err
+						if err := gitPull(cmd.Context(), path.Join(gitProjectDir, subProject.Name()), verbose); err != nil {
+							if !forceOnError {
+								return err
+							}
+							fmt.Fprintln(os.Stderr, err)
+							failures++
 						}
 					}
 				}
 			}
-			return err
+
+			if failures > 0 {
+				return errors.New(fmt.Sprintf("%d project(s) could not be updated", failures))
+			}
+			return nil
 		},
 	}
 	cmd.Flags().BoolVarP(&forceOnError, "force", "f", false, "Force updating subprojects when an error occurs")
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output of update")
 	return cmd
 }
+
+// gitPull pulls the git repository in the directory. Its output is only shown in verbose mode or when it fails.
+func gitPull(ctx context.Context, dir string, verbose bool) error {
+	command := pkg.Command{Name: "git", Args: []string{"pull"}, Dir: dir}
+	if verbose {
+		_, err := runner.Run(ctx, command)
+		return err
+	}
+	_, err := runner.Output(ctx, command)
+	return err
+}
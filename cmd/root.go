@@ -1,19 +1,32 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"runtime"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"io.twasyl/devcore/pkg/config"
+	"io.twasyl/devcore/pkg/output"
+	du "io.twasyl/devcore/pkg/utils"
 )
 
+// runner runs the processes of the commands. It is replaced by a fake runner in the tests.
+var runner du.Runner = &du.ExecRunner{}
+
 var rootCmd = &cobra.Command{
 	Use:   "devcore",
 	Short: "devcore provides developers/development utilities",
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&config.ConfigFile, "config", "", "The configuration file to use, in JSON or YAML. Defaults to DEVCORE_CONFIG or the config file of the devcore directory")
+	rootCmd.PersistentFlags().BoolVar(&du.DryRun, "dry-run", false, "Print the commands, downloads and file changes instead of making them")
+	rootCmd.PersistentFlags().VarP(&output.Selected, "output", "o", "The output format of the listing and status commands: table, wide, json, yaml or go-template=<template>")
+	rootCmd.PersistentFlags().StringVar(&config.ProfileName, "profile", "", "The profile to use instead of the current one. Defaults to DEVCORE_PROFILE")
+
 	cmd := &cobra.Command{
 		Use:   "install",
 		Short: "Install devcore in your environment",
@@ -26,10 +39,115 @@ func init() {
 	rootCmd.AddCommand(cmd)
 }
 
-// Execute will execute the `devcore command`
+// Execute will execute the `devcore command`, with the configuration loaded beforehand and saved afterwards.
 func Execute() {
+	// The configuration is needed to find the plugins, before the flags are parsed
+	args := devcoreArgs(os.Args[1:])
+	config.ConfigFile = flagValue(args, "config")
+	config.ProfileName = flagValue(args, "profile")
+	du.DryRun = hasFlag(args, "dry-run")
+	if err := config.Load(); err != nil {
+		fmt.Fprintln(os.Stderr, "Can not load the configuration:", err)
+		os.Exit(1)
+	}
+
+	if needsPlugins(os.Args[1:]) {
+		registerPlugins()
+	}
+
 	if err := rootCmd.Execute(); err != nil {
+		var exit *pluginExit
+		if errors.As(err, &exit) {
+			os.Exit(exit.code)
+		}
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+
+	if err := config.Save(); err != nil {
+		fmt.Fprintln(os.Stderr, "Can not save the configuration:", err)
+		os.Exit(1)
+	}
+}
+
+// devcoreArgs returns the arguments parsed by devcore itself: all of them for the built-in commands but, for the
+// plugins, only the ones preceding the plugin's name. The arguments following it belong to the plugin.
+func devcoreArgs(args []string) []string {
+	index := commandIndex(args)
+	if index < len(args) && args[index] != "--" && isBuiltinCommand(args[index]) {
+		return args
+	}
+	return args[:index]
+}
+
+// needsPlugins tells if the plugins are needed to run the command: either a plugin or the help, listing them. The
+// built-in commands, like the env command evaluated each time the shell changes directory, don't look for them.
+func needsPlugins(args []string) bool {
+	index := commandIndex(args)
+	return index == len(args) || args[index] == "--" || args[index] == "help" || !isBuiltinCommand(args[index])
+}
+
+// commandIndex returns the index of the name of the command in the arguments, which is the first one being neither a
+// global flag nor its value, or the index of `--`. The length of the arguments is returned when there is none.
+func commandIndex(args []string) int {
+	for index := 0; index < len(args); index++ {
+		if arg := args[index]; arg == "--" || !strings.HasPrefix(arg, "-") {
+			return index
+		} else if takesValue(arg) {
+			// Skips the value of the flag, which is not the name of a command
+			index++
+		}
+	}
+	return len(args)
+}
+
+// takesValue tells if the argument is a global flag whose value is the next argument, like --config in --config FILE.
+func takesValue(arg string) bool {
+	if strings.Contains(arg, "=") {
+		return false
+	}
+
+	name := strings.TrimLeft(arg, "-")
+	if strings.HasPrefix(arg, "--") {
+		flag := rootCmd.PersistentFlags().Lookup(name)
+		return flag != nil && flag.NoOptDefVal == ""
+	} else if len(name) == 1 {
+		flag := rootCmd.PersistentFlags().ShorthandLookup(name)
+		return flag != nil && flag.NoOptDefVal == ""
+	}
+	return false
+}
+
+// flagValue returns the value of the flag with the given name found in the arguments.
+func flagValue(args []string, name string) string {
+	for index, arg := range args {
+		if arg == "--" {
+			break
+		} else if arg == "--"+name && index+1 < len(args) {
+			return args[index+1]
+		} else if strings.HasPrefix(arg, "--"+name+"=") {
+			return strings.TrimPrefix(arg, "--"+name+"=")
+		}
+	}
+	return ""
+}
+
+// hasFlag tells if the boolean flag with the given name is set in the arguments.
+func hasFlag(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		} else if arg == "--"+name || arg == "--"+name+"=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// currentMarker returns the mark of the current element of a list in the table output.
+func currentMarker(current bool) string {
+	if current {
+		return "*"
+	}
+	return ""
 }
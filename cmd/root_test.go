@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDevcoreArgs(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"--config", "team.yaml", "tools", "list", "--dry-run"}, []string{"--config", "team.yaml", "tools", "list", "--dry-run"}},
+		{[]string{"-o", "json", "--profile=work", "plugin", "list"}, []string{"-o", "json", "--profile=work", "plugin", "list"}},
+		{[]string{"--dry-run", "deploy", "--config", "app.yaml", "--dry-run"}, []string{"--dry-run"}},
+		{[]string{"deploy", "--profile", "prod"}, []string{}},
+		{[]string{"tools", "list", "--", "--dry-run"}, []string{"tools", "list", "--", "--dry-run"}},
+		{[]string{"--", "--dry-run"}, []string{}},
+	}
+
+	for _, test := range tests {
+		if args := devcoreArgs(test.args); !reflect.DeepEqual(args, test.expected) {
+			t.Errorf("devcoreArgs(%q) = %q, expected %q", test.args, args, test.expected)
+		}
+	}
+}
+
+func TestNeedsPlugins(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected bool
+	}{
+		{[]string{}, true},
+		{[]string{"--dry-run", "deploy", "--force"}, true},
+		{[]string{"help", "deploy"}, true},
+		{[]string{"--config", "env", "env", "--shell", "zsh"}, false},
+		{[]string{"tools", "list"}, false},
+	}
+
+	for _, test := range tests {
+		if needsPlugins(test.args) != test.expected {
+			t.Errorf("needsPlugins(%q) = %t, expected %t", test.args, !test.expected, test.expected)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"io.twasyl/devcore/pkg/config"
+	du "io.twasyl/devcore/pkg/utils"
+	"io.twasyl/devcore/pkg/utils/utilstest"
+)
+
+// runDevcore executes devcore with the arguments, its processes being run by the fake runner.
+func runDevcore(t *testing.T, fake *utilstest.FakeRunner, args ...string) error {
+	previous := runner
+	runner = fake
+	t.Cleanup(func() {
+		runner = previous
+	})
+
+	rootCmd.SetArgs(args)
+	return rootCmd.Execute()
+}
+
+func TestKindToken(t *testing.T) {
+	fake := utilstest.NewFakeRunner().
+		On("kubectl -n kubernetes-dashboard get sa/admin-user -o jsonpath={.secrets[0].name}", du.Result{Stdout: "admin-user-token-x2z4\n"}).
+		On("kubectl -n kubernetes-dashboard get secret admin-user-token-x2z4 -o go-template={{.data.token | base64decode}}", du.Result{Stdout: "eyJhbGciOi\n"}).
+		On("xclip -selection c", du.Result{}).
+		On("pbcopy", du.Result{}).
+		On("xdg-open http://localhost:8001/api/v1/namespaces/kubernetes-dashboard/services/https:kubernetes-dashboard:/proxy/", du.Result{}).
+		On("open http://localhost:8001/api/v1/namespaces/kubernetes-dashboard/services/https:kubernetes-dashboard:/proxy/", du.Result{})
+
+	if err := runDevcore(t, fake, "kind", "token"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fake.Calls) != 4 {
+		t.Fatalf("Expected 4 commands, got %v", fake.Lines())
+	}
+	copied, _ := io.ReadAll(fake.Calls[2].Stdin)
+	if string(copied) != "eyJhbGciOi" {
+		t.Errorf("Expected the token to be copied, got %q", copied)
+	}
+}
+
+func TestKindTokenWithoutSecret(t *testing.T) {
+	fake := utilstest.NewFakeRunner().
+		On("kubectl -n kubernetes-dashboard get sa/admin-user -o jsonpath={.secrets[0].name}", du.Result{})
+
+	if err := runDevcore(t, fake, "kind", "token"); err == nil {
+		t.Error("Expected an error when the service account has no secret")
+	}
+	if len(fake.Calls) != 1 {
+		t.Errorf("Expected no command after the failure, got %v", fake.Lines())
+	}
+}
+
+func TestHelmInstallFailure(t *testing.T) {
+	fake := utilstest.NewFakeRunner().
+		On("kubectl create namespace monitoring", du.Result{ExitCode: 1, Stderr: `Error from server (AlreadyExists): namespaces "monitoring" already exists`}).
+		On("helm install prometheus prometheus-community/prometheus -n monitoring", du.Result{ExitCode: 1, Stderr: "Error: INSTALLATION FAILED: cannot re-use a name that is still in use"})
+
+	err := runDevcore(t, fake, "helm", "install", "prometheus", "prometheus-community/prometheus", "-n", "monitoring")
+
+	var exitError *du.ExitError
+	if !errors.As(err, &exitError) || exitError.ExitCode != 1 {
+		t.Errorf("Expected the exit code of helm to be reported, got %v", err)
+	}
+	if len(fake.Calls) != 2 {
+		t.Errorf("Expected helm to be run despite the existing namespace, got %v", fake.Lines())
+	}
+}
+
+func TestHelmInstallNamespaceFailure(t *testing.T) {
+	fake := utilstest.NewFakeRunner().
+		On("kubectl create namespace monitoring", du.Result{ExitCode: 1, Stderr: "error: You must be logged in to the server (Unauthorized)"})
+
+	err := runDevcore(t, fake, "helm", "install", "prometheus", "prometheus-community/prometheus", "-n", "monitoring")
+
+	if err == nil || !strings.Contains(err.Error(), "Unauthorized") {
+		t.Errorf("Expected the error of kubectl to be reported, got %v", err)
+	}
+	if len(fake.Calls) != 1 {
+		t.Errorf("Expected helm not to be run, got %v", fake.Lines())
+	}
+}
+
+func TestProjectUpdate(t *testing.T) {
+	projectsDir := t.TempDir()
+	for _, dir := range []string{"devcore/.git", "devcore/api/.git", "devcore/docs"} {
+		if err := os.MkdirAll(filepath.Join(projectsDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	previous := config.Config.ProjectsDir
+	config.Config.ProjectsDir = projectsDir
+	defer func() {
+		config.Config.ProjectsDir = previous
+	}()
+
+	fake := utilstest.NewFakeRunner().On("git pull", du.Result{Stdout: "Already up to date.\n"})
+	if err := runDevcore(t, fake, "project", "update", "devcore", "--force=false"); err != nil {
+		t.Fatal(err)
+	}
+
+	dirs := make([]string, 0)
+	for _, command := range fake.Calls {
+		dirs = append(dirs, command.Dir)
+	}
+	expected := []string{filepath.Join(projectsDir, "devcore"), filepath.Join(projectsDir, "devcore", "api")}
+	if !reflect.DeepEqual(dirs, expected) {
+		t.Errorf("Expected git pull in %v, got %v", expected, dirs)
+	}
+
+	fake = utilstest.NewFakeRunner().On("git pull", du.Result{ExitCode: 128, Stderr: "fatal: not a git repository"})
+	if err := runDevcore(t, fake, "project", "update", "devcore", "--force=false"); err == nil {
+		t.Error("Expected the failure of git pull to be reported")
+	} else if len(fake.Calls) != 1 {
+		t.Errorf("Expected the update to stop at the first failure, got %v", fake.Lines())
+	}
+
+	fake = utilstest.NewFakeRunner().On("git pull", du.Result{ExitCode: 128, Stderr: "fatal: not a git repository"})
+	if err := runDevcore(t, fake, "project", "update", "devcore", "--force"); err == nil {
+		t.Error("Expected the failures of git pull to be reported when forced")
+	} else if len(fake.Calls) != 2 {
+		t.Errorf("Expected the subprojects to be updated when forced, got %v", fake.Lines())
+	}
+}
+
+func TestDockerComposeContextStart(t *testing.T) {
+	previous := config.Config.DockerCompose
+	config.Config.DockerCompose = config.DockerCompose{
+		CurrentContext: "backend",
+		Contexts: []config.DockerComposeContext{
+			{Name: "backend", File: "/srv/backend/docker-compose.yml"},
+			{Name: "frontend", File: "/srv/frontend/docker-compose.yml"},
+		},
+	}
+	defer func() {
+		config.Config.DockerCompose = previous
+	}()
+
+	fake := utilstest.NewFakeRunner().On("docker compose -f /srv/frontend/docker-compose.yml up -d", du.Result{})
+	if err := runDevcore(t, fake, "docker-compose", "context", "start", "frontend"); err != nil {
+		t.Fatal(err)
+	}
+
+	fake = utilstest.NewFakeRunner().On("docker compose -f /srv/backend/docker-compose.yml up -d", du.Result{ExitCode: 1, Stderr: "no such service: db"})
+	err := runDevcore(t, fake, "docker-compose", "context", "start")
+	if err == nil || !strings.Contains(err.Error(), "no such service: db") {
+		t.Errorf("Expected the failure of docker compose to be reported, got %v", err)
+	}
+}
+
+func TestDockerComposeContextLifecycle(t *testing.T) {
+	previous := config.Config.DockerCompose
+	config.Config.DockerCompose = config.DockerCompose{
+		CurrentContext: "backend",
+		Contexts: []config.DockerComposeContext{
+			{Name: "backend", File: "/srv/backend/docker-compose.yml"},
+			{Name: "frontend", File: "/srv/frontend/docker-compose.yml", StopPolicy: config.StopPolicyDown},
+		},
+	}
+	defer func() {
+		config.Config.DockerCompose = previous
+		rootCmd.SetIn(nil)
+	}()
+
+	for _, input := range []string{"n\n", ""} {
+		fake := utilstest.NewFakeRunner()
+		rootCmd.SetIn(strings.NewReader(input))
+		if err := runDevcore(t, fake, "docker-compose", "context", "destroy"); err == nil {
+			t.Errorf("Expected destroy to fail when not confirmed with %q", input)
+		} else if len(fake.Lines()) != 0 {
+			t.Errorf("Expected destroy not to run anything when not confirmed with %q, ran %v", input, fake.Lines())
+		}
+	}
+
+	tests := []struct {
+		args     []string
+		input    string
+		expected []string
+	}{
+		{[]string{"stop"}, "", []string{"docker compose -f /srv/backend/docker-compose.yml stop"}},
+		{[]string{"stop", "frontend"}, "", []string{"docker compose -f /srv/frontend/docker-compose.yml down"}},
+		{[]string{"down"}, "", []string{"docker compose -f /srv/backend/docker-compose.yml down"}},
+		{[]string{"restart"}, "", []string{"docker compose -f /srv/backend/docker-compose.yml restart"}},
+		{[]string{"restart", "--", "api", "db"}, "", []string{"docker compose -f /srv/backend/docker-compose.yml restart api db"}},
+		{[]string{"restart", "frontend", "--", "web"}, "", []string{"docker compose -f /srv/frontend/docker-compose.yml restart web"}},
+		{[]string{"destroy"}, "y\n", []string{"docker compose -f /srv/backend/docker-compose.yml down --volumes"}},
+		{[]string{"destroy", "--yes", "frontend"}, "", []string{"docker compose -f /srv/frontend/docker-compose.yml down --volumes"}},
+	}
+
+	for _, test := range tests {
+		fake := utilstest.NewFakeRunner()
+		for _, line := range test.expected {
+			fake.On(line, du.Result{})
+		}
+		rootCmd.SetIn(strings.NewReader(test.input))
+
+		if err := runDevcore(t, fake, append([]string{"docker-compose", "context"}, test.args...)...); err != nil {
+			t.Errorf("%v failed: %s", test.args, err)
+		} else if !reflect.DeepEqual(fake.Lines(), test.expected) {
+			t.Errorf("%v ran %v, expected %v", test.args, fake.Lines(), test.expected)
+		}
+	}
+}
+
+func TestToolsUpgradeSkipsToolsWithoutReleaseSource(t *testing.T) {
+	prefix := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(prefix, "tools", "maven", "3.8.5"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("3.8.5", filepath.Join(prefix, "tools", "maven", "current")); err != nil {
+		t.Fatal(err)
+	}
+	previous := config.Config.InstallPrefix
+	config.Config.InstallPrefix = prefix
+	defer func() {
+		config.Config.InstallPrefix = previous
+		upgradeAll = false
+	}()
+
+	fake := utilstest.NewFakeRunner()
+	if err := runDevcore(t, fake, "tools", "upgrade", "--all"); err != nil {
+		t.Errorf("Expected maven, which has no release source, to be skipped: %s", err)
+	}
+	if len(fake.Lines()) != 0 {
+		t.Errorf("Expected nothing to be run, ran %v", fake.Lines())
+	}
+}
+
+func TestToolsPinnedByRange(t *testing.T) {
+	prefix := t.TempDir()
+	for _, version := range []string{"1.23.6", "1.24.3"} {
+		if err := os.MkdirAll(filepath.Join(prefix, "tools", "kubectl", version), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	project := filepath.Join(prefix, "projects", "api")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, config.ToolVersionsFile), []byte("kubectl ~1.24\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	workingDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(project); err != nil {
+		t.Fatal(err)
+	}
+	previousPrefix, previousProjectsDir := config.Config.InstallPrefix, config.Config.ProjectsDir
+	config.Config.InstallPrefix, config.Config.ProjectsDir = prefix, filepath.Join(prefix, "projects")
+	defer func() {
+		config.Config.InstallPrefix, config.Config.ProjectsDir = previousPrefix, previousProjectsDir
+		os.Chdir(workingDir)
+	}()
+
+	for _, args := range [][]string{{"tools", "sync"}, {"tools", "which", "kubectl"}} {
+		fake := utilstest.NewFakeRunner()
+		if err := runDevcore(t, fake, args...); err != nil {
+			t.Errorf("Expected kubectl 1.24.3 to satisfy the ~1.24 pin for %v: %s", args, err)
+		} else if len(fake.Lines()) != 0 {
+			t.Errorf("Expected %v not to run anything, ran %v", args, fake.Lines())
+		}
+	}
+}
@@ -2,13 +2,19 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"io.twasyl/devcore/pkg/config"
+	"io.twasyl/devcore/pkg/output"
+	du "io.twasyl/devcore/pkg/utils"
 )
 
 func init() {
 	rootCmd.AddCommand(serversCommand)
+	serversCommand.PersistentFlags().BoolVar(&config.Offline, "offline", false, "Only install artifacts present in the download cache")
 
 	validArgs := make([]string, 0)
 	for _, server := range config.DefaultSupportedServers {
@@ -17,6 +23,7 @@ func init() {
 	serversInstallCmd.ValidArgs = validArgs
 
 	serversCommand.AddCommand(serversInstallCmd)
+	serversCommand.AddCommand(serversListCmd)
 	serversInstallCmd.PersistentFlags().StringVarP(&serverVersion, "version", "v", "", "The version of the server to install")
 }
 
@@ -28,6 +35,48 @@ var serversCommand = &cobra.Command{
 	Short: "Provide facilitators for developers' servers",
 }
 
+var serversListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the supported servers and their installed versions",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		servers := make([]serverItem, 0)
+		for _, server := range config.DefaultSupportedServers {
+			versions, err := server.InstalledVersions()
+			if err != nil {
+				return err
+			}
+			servers = append(servers, serverItem{server, versions, filepath.Join(config.Config.ServersDir, server.Name)})
+		}
+		return serverPrinter.Print(os.Stdout, servers)
+	},
+}
+
+// serverItem is a supported server as listed.
+type serverItem struct {
+	config.Server
+	InstalledVersions []string `json:"installed-versions"`
+	Dir               string   `json:"dir"`
+}
+
+var serverPrinter = output.Printer{
+	Columns: []output.Column{
+		{Header: "NAME", Value: func(item interface{}) string {
+			return item.(serverItem).Name
+		}},
+		{Header: "DEFAULT VERSION", Value: func(item interface{}) string {
+			return item.(serverItem).DefaultVersion
+		}},
+		{Header: "INSTALLED", Value: func(item interface{}) string {
+			return strings.Join(item.(serverItem).InstalledVersions, ", ")
+		}},
+		{Header: "DIR", Wide: true, Value: func(item interface{}) string {
+			return item.(serverItem).Dir
+		}},
+	},
+}
+
 var serversInstallCmd = &cobra.Command{
 	Use:   "install",
 	Short: "Install a server",
@@ -47,9 +96,12 @@ var serversInstallCmd = &cobra.Command{
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Println(fmt.Sprintf("Installing %s %s", server.Name, serverVersion))
-		return server.Install(serverVersion)
+		return server.Install(runner, serverVersion)
 	},
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if du.DryRun {
+			return
+		}
 		fmt.Println(fmt.Sprintf("%s installed successfully", server.Name))
 	},
 }
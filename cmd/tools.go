@@ -1,31 +1,57 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"runtime"
 
 	"github.com/spf13/cobra"
 	"io.twasyl/devcore/pkg/config"
+	"io.twasyl/devcore/pkg/output"
+	du "io.twasyl/devcore/pkg/utils"
+	"io.twasyl/devcore/pkg/version"
 )
 
 func init() {
 	rootCmd.AddCommand(toolsCommand)
-
-	validArgs := make([]string, 0)
-	for _, tool := range config.DefaultSupportedTools {
-		validArgs = append(validArgs, tool.Name)
-	}
-	toolsInstallCmd.ValidArgs = validArgs
+	toolsCommand.PersistentFlags().BoolVar(&config.Offline, "offline", false, "Only install artifacts present in the download cache")
 
 	toolsCommand.AddCommand(toolsInstallCmd)
-	toolsInstallCmd.PersistentFlags().StringVarP(&toolVersion, "version", "v", "", "The version of the tool to install")
+	toolsInstallCmd.PersistentFlags().StringVarP(&toolVersion, "version", "v", "", "The version of the tool to install: an exact version, latest or a range like 1.25.x, ~3.9 or ^1.2. Defaults to the version configured in default-tools-version")
+	toolsInstallCmd.PersistentFlags().StringVar(&toolArch, "arch", runtime.GOARCH, "The architecture to install the tool for, like amd64 or arm64")
 
 	toolsCommand.AddCommand(toolsListCmd)
 	toolsListCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Verbose mode")
+	toolsListCmd.PersistentFlags().MarkDeprecated("verbose", "use --output wide instead")
+
+	toolsCommand.AddCommand(toolsUseCmd)
+	toolsCommand.AddCommand(toolsVersionsCmd)
+	toolsCommand.AddCommand(toolsUninstallCmd)
+
+	toolsCommand.AddCommand(toolsStatusCmd)
+	toolsStatusCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output the status in JSON")
+	toolsStatusCmd.Flags().MarkDeprecated("json", "use --output json instead")
+
+	toolsCommand.AddCommand(toolsOutdatedCmd)
+	toolsOutdatedCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output the outdated tools in JSON")
+	toolsOutdatedCmd.Flags().MarkDeprecated("json", "use --output json instead")
+
+	toolsCommand.AddCommand(toolsSyncCmd)
+	toolsCommand.AddCommand(toolsWhichCmd)
+
+	toolsCommand.AddCommand(toolsUpgradeCmd)
+	toolsUpgradeCmd.Flags().BoolVarP(&upgradeAll, "all", "a", false, "Upgrade all the tools installed by devcore")
+	toolsUpgradeCmd.Flags().StringVarP(&upgradeVersion, "version", "v", version.Latest, "The versions to upgrade to: latest or a range like 1.25.x, ~3.9 or ^1.2")
 }
 
 var tool config.Tool
 var toolVersion string
+var toolArch string
 var verbose bool
+var jsonOutput bool
+var upgradeAll bool
+var upgradeVersion string
 
 var toolsCommand = &cobra.Command{
 	Use:   "tools",
@@ -33,9 +59,10 @@ var toolsCommand = &cobra.Command{
 }
 
 var toolsInstallCmd = &cobra.Command{
-	Use:   "install",
-	Short: "Install a tool",
-	Args:  cobra.ExactArgs(1),
+	Use:               "install",
+	Short:             "Install a tool",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeToolNames,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		var err error
 		tool, err = config.FindTool(args[0])
@@ -44,17 +71,31 @@ var toolsInstallCmd = &cobra.Command{
 		}
 
 		if toolVersion == "" {
-			toolVersion = tool.DefaultVersion
+			toolVersion = tool.ConfiguredVersion()
 		}
 
-		return nil
+		toolVersion, err = tool.ResolveVersion(toolVersion)
+		return err
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println(fmt.Sprintf("Installing %s %s", tool.Name, toolVersion))
-		return tool.Install(toolVersion)
+		platform := config.Platform{OS: runtime.GOOS, Arch: toolArch}
+		if tool.IsInstalled(toolVersion, platform) {
+			if platform != config.CurrentPlatform() {
+				fmt.Println(fmt.Sprintf("%s %s for %s is already installed in %s", tool.Name, toolVersion, platform, tool.PlatformVersionDir(toolVersion, platform)))
+				return nil
+			}
+			fmt.Println(fmt.Sprintf("%s %s is already installed, using it", tool.Name, toolVersion))
+			return tool.Use(toolVersion)
+		}
+
+		return installTool(tool, toolVersion, platform)
 	},
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if du.DryRun || toolArch != runtime.GOARCH {
+			return
+		}
 		fmt.Println(fmt.Sprintf("%s installed successfully", tool.Name))
+		warnIfBinDirNotInPath()
 	},
 }
 
@@ -62,15 +103,429 @@ var toolsListCmd = &cobra.Command{
 	Use:     "list",
 	Aliases: []string{"ls"},
 	Short:   "List supported tools",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if verbose {
+			output.Selected = output.Format{Name: output.Wide}
+		}
+
+		tools := make([]toolItem, 0)
+		for _, tool := range config.SupportedTools {
+			tools = append(tools, toolItem{tool, tool.ConfiguredVersion()})
+		}
+		return toolPrinter.Print(os.Stdout, tools)
+	},
+}
+
+// toolItem is a supported tool as listed.
+type toolItem struct {
+	config.Tool
+	ConfiguredVersion string `json:"configured-version"`
+}
+
+var toolPrinter = output.Printer{
+	Columns: []output.Column{
+		{Header: "NAME", Value: func(item interface{}) string {
+			return item.(toolItem).Name
+		}},
+		{Header: "VERSION", Value: func(item interface{}) string {
+			return item.(toolItem).ConfiguredVersion
+		}},
+		{Header: "DESCRIPTION", Value: func(item interface{}) string {
+			return item.(toolItem).Description
+		}},
+		{Header: "COMMAND", Wide: true, Value: func(item interface{}) string {
+			return item.(toolItem).CommandLineName
+		}},
+		{Header: "URL", Wide: true, Value: func(item interface{}) string {
+			return item.(toolItem).URL
+		}},
+	},
+}
+
+var toolsUseCmd = &cobra.Command{
+	Use:               "use <tool> <version>",
+	Short:             "Use an installed version of a tool",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeToolNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tool, err := config.FindTool(args[0])
+		if err != nil {
+			return err
+		}
+
+		err = tool.Use(args[1])
+		if err == nil {
+			fmt.Println(fmt.Sprintf("Now using %s %s", tool.Name, args[1]))
+		}
+		return err
+	},
+}
+
+var toolsVersionsCmd = &cobra.Command{
+	Use:               "versions <tool>",
+	Short:             "List the installed versions of a tool",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeToolNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tool, err := config.FindTool(args[0])
+		if err != nil {
+			return err
+		}
+
+		versions, err := installedVersions(tool)
+		if err != nil {
+			return err
+		}
+
+		if len(versions) == 0 && output.Selected.IsTable() {
+			fmt.Println(fmt.Sprintf("No version of %s installed", tool.Name))
+			return nil
+		}
+		return installedVersionPrinter.Print(os.Stdout, versions)
+	},
+}
+
+var toolsUninstallCmd = &cobra.Command{
+	Use:               "uninstall <tool> [version]",
+	Short:             "Uninstall a tool",
+	Long:              "Uninstall a version of a tool, or all its versions when none is specified",
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeToolNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tool, err := config.FindTool(args[0])
+		if err != nil {
+			return err
+		}
+
+		versions := args[1:]
+		if len(versions) == 0 {
+			if versions, err = tool.InstalledVersions(); err != nil {
+				return err
+			}
+		}
+
+		for _, version := range versions {
+			if err := tool.Uninstall(version); err != nil {
+				return err
+			}
+			fmt.Println(fmt.Sprintf("%s %s uninstalled", tool.Name, version))
+		}
+		return nil
+	},
+}
+
+var toolsUpgradeCmd = &cobra.Command{
+	Use:               "upgrade [tool...]",
+	Short:             "Upgrade installed tools to their newest version",
+	ValidArgsFunction: completeToolNames,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && !upgradeAll {
+			return errors.New("Specify the tools to upgrade or use --all")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tools := make([]config.Tool, 0)
+		if upgradeAll {
+			for _, tool := range config.SupportedTools {
+				if tool.ActiveVersion() != "" {
+					tools = append(tools, tool)
+				}
+			}
+		} else {
+			for _, name := range args {
+				tool, err := config.FindTool(name)
+				if err != nil {
+					return err
+				}
+				tools = append(tools, tool)
+			}
+		}
+
+		for _, tool := range tools {
+			activeVersion := tool.ActiveVersion()
+			if activeVersion == "" {
+				fmt.Println(fmt.Sprintf("%s is not installed by devcore, skipping it", tool.Name))
+				continue
+			}
+
+			if !version.IsExact(upgradeVersion) && !tool.HasReleaseSource() {
+				fmt.Println(fmt.Sprintf("%s has no release source, skipped", tool.Name))
+				continue
+			}
+
+			newest, err := tool.ResolveVersion(upgradeVersion)
+			if err != nil {
+				return err
+			}
+
+			if version.Compare(newest, activeVersion) <= 0 {
+				fmt.Println(fmt.Sprintf("%s %s is up to date", tool.Name, activeVersion))
+			} else if tool.IsInstalled(newest, config.CurrentPlatform()) {
+				fmt.Println(fmt.Sprintf("Upgrading %s from %s to already installed %s", tool.Name, activeVersion, newest))
+				if err := tool.Use(newest); err != nil {
+					return err
+				}
+			} else if err := installTool(tool, newest, config.CurrentPlatform()); err != nil {
+				return err
+			} else {
+				fmt.Println(fmt.Sprintf("%s upgraded from %s to %s", tool.Name, activeVersion, newest))
+			}
+		}
+		return nil
+	},
+}
+
+var toolsSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: fmt.Sprintf("Install the tool versions pinned by the %s file of the current project", config.ToolVersionsFile),
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := config.FindToolVersionsFile(".")
+		if err != nil {
+			return err
+		} else if file == "" {
+			return fmt.Errorf("No %s file found in the current directory or its parents", config.ToolVersionsFile)
+		}
+
+		pins, err := config.ReadToolVersions(file)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(fmt.Sprintf("Syncing tools pinned in %s", file))
+		for _, pin := range pins {
+			tool, err := config.FindTool(pin.Name)
+			if err != nil {
+				return err
+			}
+
+			if installed, err := tool.InstalledPinnedVersion(pin.Version); err == nil {
+				fmt.Println(fmt.Sprintf("%s %s is already installed", tool.Name, installed))
+				continue
+			} else if !config.IsToolVersionNotInstalled(err) {
+				return err
+			}
+
+			pinnedVersion, err := tool.ResolveVersion(pin.Version)
+			if err != nil {
+				return err
+			}
+			if err := installTool(tool, pinnedVersion, config.CurrentPlatform()); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+var toolsWhichCmd = &cobra.Command{
+	Use:               "which <tool>",
+	Short:             "Print the path of the binary of a tool to use in the current directory",
+	Long:              fmt.Sprintf("Print the path of the binary of the tool version pinned by the %s file of the current project, or of the active version when the tool isn't pinned", config.ToolVersionsFile),
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeToolNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tool, err := config.FindTool(args[0])
+		if err != nil {
+			return err
+		}
+
+		pinnedVersion, file, err := config.PinnedVersion(".", tool.Name)
+		if err != nil {
+			return err
+		}
+
+		var binary string
+		if pinnedVersion != "" {
+			installed, pinErr := tool.InstalledPinnedVersion(pinnedVersion)
+			if config.IsToolVersionNotInstalled(pinErr) {
+				return fmt.Errorf("%s %s is pinned by %s but not installed, use 'devcore tools sync'", tool.Name, pinnedVersion, file)
+			} else if pinErr != nil {
+				return pinErr
+			}
+			binary, err = tool.BinaryPath(installed)
+		} else if activeVersion := tool.ActiveVersion(); activeVersion != "" {
+			binary, err = tool.BinaryPath(activeVersion)
+		} else {
+			binary, err = tool.FindBinary()
+		}
+
+		if err != nil {
+			return &config.ToolNotFound{Name: tool.Name}
+		}
+		fmt.Println(binary)
+		return nil
+	},
+}
+
+// installTool installs the version of the tool built for the platform. When the platform is the current one, it
+// checks the installed binary can be executed. Builds for other platforms are neither checked nor linked.
+func installTool(tool config.Tool, toolVersion string, platform config.Platform) error {
+	fmt.Println(fmt.Sprintf("Installing %s %s for %s", tool.Name, toolVersion, platform))
+	previousVersion := tool.ActiveVersion()
+	if err := tool.Install(runner, toolVersion, platform); err != nil {
+		return err
+	}
+
+	if du.DryRun {
+		return nil
+	} else if platform != config.CurrentPlatform() {
+		fmt.Println(fmt.Sprintf("%s %s for %s installed in %s, it is not linked in the bin directory", tool.Name, toolVersion, platform, tool.PlatformVersionDir(toolVersion, platform)))
+		return nil
+	}
+
+	warning, err := tool.CheckInstallation(runner, toolVersion)
+	if err != nil {
+		tool.Uninstall(toolVersion)
+		if previousVersion != "" {
+			tool.Use(previousVersion)
+		}
+		return fmt.Errorf("%s %s has been removed because it can not be executed: %w", tool.Name, toolVersion, err)
+	} else if warning != "" {
+		fmt.Println(fmt.Sprintf("Warning: %s", warning))
+	}
+	return nil
+}
+
+var toolsStatusCmd = &cobra.Command{
+	Use:               "status [tool...]",
+	Short:             "Compare the installed versions of the tools to the configured ones",
+	ValidArgsFunction: completeToolNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		statuses, err := toolStatuses(args)
+		if err != nil {
+			return err
+		}
+		return printToolStatuses(statuses)
+	},
+}
+
+var toolsOutdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "List the installed tools older than their configured version",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		statuses, err := toolStatuses(args)
+		if err != nil {
+			return err
+		}
+
+		outdated := make([]config.ToolStatus, 0)
+		for _, status := range statuses {
+			if status.Status == config.ToolOlder {
+				outdated = append(outdated, status)
+			}
+		}
+		return printToolStatuses(outdated)
+	},
+}
 
-		fmt.Println("Supported tools:")
-		for _, tool := range config.DefaultSupportedTools {
-			fmt.Printf("  - %s\n", tool.Name)
-			if verbose {
-				fmt.Printf("    Default version: %s\n", tool.DefaultVersion)
-				fmt.Printf("    Description: %s\n", tool.Description)
+// toolStatuses returns the status of the given tools, or of all supported tools when none is given.
+func toolStatuses(names []string) ([]config.ToolStatus, error) {
+	tools := config.SupportedTools
+	if len(names) > 0 {
+		tools = make([]config.Tool, 0)
+		for _, name := range names {
+			tool, err := config.FindTool(name)
+			if err != nil {
+				return nil, err
 			}
+			tools = append(tools, tool)
 		}
+	}
+
+	statuses := make([]config.ToolStatus, 0)
+	for _, tool := range tools {
+		statuses = append(statuses, tool.Status(runner))
+	}
+	return statuses, nil
+}
+
+func printToolStatuses(statuses []config.ToolStatus) error {
+	if jsonOutput {
+		output.Selected = output.Format{Name: output.JSON}
+	}
+	return toolStatusPrinter.Print(os.Stdout, statuses)
+}
+
+var toolStatusPrinter = output.Printer{
+	Columns: []output.Column{
+		{Header: "NAME", Value: func(item interface{}) string {
+			return item.(config.ToolStatus).Name
+		}},
+		{Header: "INSTALLED", Value: func(item interface{}) string {
+			return item.(config.ToolStatus).Installed
+		}},
+		{Header: "CONFIGURED", Value: func(item interface{}) string {
+			return item.(config.ToolStatus).Configured
+		}},
+		{Header: "STATUS", Value: func(item interface{}) string {
+			return item.(config.ToolStatus).Status
+		}},
+		{Header: "PATH", Value: func(item interface{}) string {
+			return item.(config.ToolStatus).Path
+		}},
+		{Header: "ERROR", Wide: true, Value: func(item interface{}) string {
+			return item.(config.ToolStatus).Error
+		}},
+	},
+}
+
+// installedVersion is an installed version of a tool as listed.
+type installedVersion struct {
+	Version string `json:"version"`
+	Active  bool   `json:"active"`
+	Dir     string `json:"dir"`
+}
+
+// installedVersions returns the installed versions of the tool, sorted from the oldest.
+func installedVersions(tool config.Tool) ([]installedVersion, error) {
+	versions, err := tool.InstalledVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	installed := make([]installedVersion, 0)
+	activeVersion := tool.ActiveVersion()
+	for _, version := range versions {
+		installed = append(installed, installedVersion{Version: version, Active: version == activeVersion, Dir: tool.VersionDir(version)})
+	}
+	return installed, nil
+}
+
+var installedVersionPrinter = output.Printer{
+	Columns: []output.Column{
+		{Header: "ACTIVE", Value: func(item interface{}) string {
+			return currentMarker(item.(installedVersion).Active)
+		}},
+		{Header: "VERSION", Value: func(item interface{}) string {
+			return item.(installedVersion).Version
+		}},
+		{Header: "DIR", Wide: true, Value: func(item interface{}) string {
+			return item.(installedVersion).Dir
+		}},
 	},
 }
+
+func completeToolNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return toolNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func toolNames() []string {
+	names := make([]string, 0)
+	for _, tool := range config.SupportedTools {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+func warnIfBinDirNotInPath() {
+	if binDir := config.Config.BinDir(); !du.IsInPath(binDir) {
+		fmt.Printf("Warning: %s is not in your PATH. Add it to your shell profile with:\n", binDir)
+		fmt.Printf("  export PATH=\"%s:$PATH\"\n", binDir)
+	}
+}
@@ -2,29 +2,53 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/spf13/cobra"
 	c "io.twasyl/devcore/pkg/config"
+	"io.twasyl/devcore/pkg/output"
 )
 
 func init() {
 	rootCmd.AddCommand(buildVersionCmd())
 }
 
+// versionInfo describes the version of devcore as printed.
+type versionInfo struct {
+	Version string          `json:"version"`
+	Tools   []supportedTool `json:"tools"`
+}
+
+// supportedTool is a tool devcore installs, with its default version.
+type supportedTool struct {
+	Name           string `json:"name"`
+	DefaultVersion string `json:"default-version"`
+}
+
 func buildVersionCmd() *cobra.Command {
 	verbose := false
 	cmd := &cobra.Command{
 		Use:   "version",
 		Short: "Display devcore version",
-		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println("devcore version 1")
-
+		RunE: func(cmd *cobra.Command, args []string) error {
 			if verbose {
-				fmt.Println("- Supported tools for installation with their default version:")
-				for _, tool := range c.DefaultSupportedTools {
-					fmt.Printf("  - %s %s\n", tool.Name, tool.DefaultVersion)
-				}
-				fmt.Println(`- Docker compose contexts can be:
+				output.Selected = output.Format{Name: output.Wide}
+			}
+
+			info := versionInfo{Version: "1", Tools: make([]supportedTool, 0)}
+			for _, tool := range c.DefaultSupportedTools {
+				info.Tools = append(info.Tools, supportedTool{Name: tool.Name, DefaultVersion: tool.DefaultVersion})
+			}
+
+			printer := output.Printer{Text: func(w io.Writer, wide bool) error {
+				fmt.Fprintf(w, "devcore version %s\n", info.Version)
+				if wide {
+					fmt.Fprintln(w, "- Supported tools for installation with their default version:")
+					for _, tool := range info.Tools {
+						fmt.Fprintf(w, "  - %s %s\n", tool.Name, tool.DefaultVersion)
+					}
+					fmt.Fprintln(w, `- Docker compose contexts can be:
   - added
   - deleted
   - listed
@@ -42,9 +66,13 @@ func buildVersionCmd() *cobra.Command {
   - created with a Kubernetes dashboard (as well as getting the connection token)
   - deleted
 - The helper allows to properly uninstall docker`)
-			}
+				}
+				return nil
+			}}
+			return printer.Print(os.Stdout, info)
 		},
 	}
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Displays more info concerning the devcore version")
+	cmd.Flags().MarkDeprecated("verbose", "use --output wide instead")
 	return cmd
 }
@@ -3,8 +3,11 @@ module io.twasyl/devcore
 go 1.18
 
 require (
+	github.com/klauspost/compress v1.15.1
 	github.com/spf13/cobra v1.4.0
 	github.com/testcontainers/testcontainers-go v0.13.0
+	github.com/ulikunitz/xz v0.5.10
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -40,5 +43,4 @@ require (
 	google.golang.org/genproto v0.0.0-20201110150050-8816d57aaa9a // indirect
 	google.golang.org/grpc v1.33.2 // indirect
 	google.golang.org/protobuf v1.27.1 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 )
@@ -0,0 +1,421 @@
+// Package archive extracts the archives downloaded when installing tools and servers. The format of an archive is
+// detected from its content rather than from its name.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+	du "io.twasyl/devcore/pkg/utils"
+)
+
+// Format is the format of a file as detected from its content.
+type Format string
+
+const (
+	Raw     Format = "raw"
+	Zip     Format = "zip"
+	Tar     Format = "tar"
+	TarGz   Format = "tar.gz"
+	TarXz   Format = "tar.xz"
+	TarBz2  Format = "tar.bz2"
+	TarZstd Format = "tar.zst"
+)
+
+// ErrNotArchive is returned when extracting a file which is not an archive, like a binary downloaded as is.
+var ErrNotArchive = errors.New("not an archive")
+
+var magics = []struct {
+	format Format
+	offset int
+	magic  []byte
+}{
+	{TarGz, 0, []byte{0x1f, 0x8b}},
+	{TarXz, 0, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{TarBz2, 0, []byte("BZh")},
+	{TarZstd, 0, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{Zip, 0, []byte{'P', 'K', 0x03, 0x04}},
+	{Zip, 0, []byte{'P', 'K', 0x05, 0x06}},
+	{Tar, 257, []byte("ustar")},
+}
+
+// Options tunes the extraction of an archive.
+type Options struct {
+	// StripComponents is the number of leading path elements removed from the entries' names. Entries having fewer
+	// elements are skipped, like `tar --strip-components` does.
+	StripComponents int
+}
+
+// DetectFormat returns the format of the given file from the magic bytes it starts with. Compressed files are expected
+// to hold a tar archive. Files of any other format are Raw.
+func DetectFormat(file string) (Format, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	header := make([]byte, 512)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	return detect(header[:n]), nil
+}
+
+func detect(header []byte) Format {
+	for _, m := range magics {
+		if len(header) >= m.offset+len(m.magic) && bytes.Equal(header[m.offset:m.offset+len(m.magic)], m.magic) {
+			return m.format
+		}
+	}
+	return Raw
+}
+
+// Extract expands the archive in the destination directory, which must exist. Entries are written with their modes and
+// symbolic and hard links are recreated. Entries, and the targets of links, must stay inside the destination directory.
+func Extract(archive string, destinationDir string, options Options) error {
+	if du.DryRun {
+		du.Plan("extract %s to %s", archive, destinationDir)
+		return nil
+	}
+
+	format, err := DetectFormat(archive)
+	if err != nil {
+		return err
+	}
+
+	root, err := filepath.EvalSymlinks(destinationDir)
+	if err != nil {
+		return err
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	e := &extractor{root: root, options: options}
+
+	switch format {
+	case Raw:
+		return fmt.Errorf("%s: %w", archive, ErrNotArchive)
+	case Zip:
+		return e.extractZip(archive)
+	}
+
+	file, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader, err := decompress(format, bufio.NewReader(file))
+	if err != nil {
+		return fmt.Errorf("%s: %w", archive, err)
+	}
+	defer reader.Close()
+
+	if err := e.extractTar(reader); err != nil {
+		return fmt.Errorf("%s: %w", archive, err)
+	}
+	return nil
+}
+
+func decompress(format Format, reader io.Reader) (io.ReadCloser, error) {
+	switch format {
+	case TarGz:
+		return gzip.NewReader(reader)
+	case TarXz:
+		xzReader, err := xz.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(xzReader), nil
+	case TarBz2:
+		return io.NopCloser(bzip2.NewReader(reader)), nil
+	case TarZstd:
+		decoder, err := zstd.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	}
+	return io.NopCloser(reader), nil
+}
+
+type extractor struct {
+	root    string
+	options Options
+}
+
+func (e *extractor) extractTar(reader io.Reader) error {
+	t := tar.NewReader(reader)
+	for {
+		header, err := t.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		name, ok := e.strip(header.Name)
+		if !ok {
+			continue
+		}
+		mode := header.FileInfo().Mode()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = e.directory(name, mode)
+		case tar.TypeReg, tar.TypeRegA:
+			err = e.file(name, mode, t)
+		case tar.TypeSymlink:
+			err = e.symlink(name, header.Linkname)
+		case tar.TypeLink:
+			target, ok := e.strip(header.Linkname)
+			if !ok {
+				return fmt.Errorf("%s: hard link target %s is stripped", header.Name, header.Linkname)
+			}
+			err = e.hardLink(name, target)
+		default:
+			// Devices, FIFOs and the like have no use in tools' archives
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (e *extractor) extractZip(archive string) error {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		name, ok := e.strip(f.Name)
+		if !ok {
+			continue
+		}
+		mode := f.Mode()
+
+		switch {
+		case mode.IsDir():
+			err = e.directory(name, mode)
+		case mode&os.ModeSymlink != 0:
+			err = e.zipSymlink(name, f)
+		case mode.IsRegular():
+			err = e.zipFile(name, mode, f)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *extractor) zipFile(name string, mode os.FileMode, f *zip.File) error {
+	content, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	return e.file(name, mode, content)
+}
+
+func (e *extractor) zipSymlink(name string, f *zip.File) error {
+	content, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	target, err := io.ReadAll(io.LimitReader(content, 4096))
+	if err != nil {
+		return err
+	}
+	return e.symlink(name, string(target))
+}
+
+// strip cleans the entry's name and removes its leading path elements as requested by the options. It returns false
+// when nothing remains of the name.
+func (e *extractor) strip(name string) (string, bool) {
+	name = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+	elements := strings.Split(name, "/")
+	if name == "" || len(elements) <= e.options.StripComponents {
+		return "", false
+	}
+	return strings.Join(elements[e.options.StripComponents:], "/"), true
+}
+
+// path returns the location of the entry in the destination directory, creating its parent directories. Parents are
+// resolved one by one, following the links already extracted, and must all be inside the destination directory.
+func (e *extractor) path(name string) (string, error) {
+	elements := strings.Split(name, "/")
+
+	current := e.root
+	for _, element := range elements[:len(elements)-1] {
+		current = filepath.Join(current, element)
+		real, err := filepath.EvalSymlinks(current)
+		if os.IsNotExist(err) {
+			err = os.Mkdir(current, 0755)
+		} else if err == nil {
+			current = real
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if !e.contains(current) {
+			return "", fmt.Errorf("%s: illegal file path", name)
+		}
+	}
+
+	location := filepath.Join(current, elements[len(elements)-1])
+	if !e.contains(location) || location == e.root {
+		return "", fmt.Errorf("%s: illegal file path", name)
+	}
+	return location, nil
+}
+
+func (e *extractor) contains(location string) bool {
+	return location == e.root || strings.HasPrefix(location, e.root+string(os.PathSeparator))
+}
+
+// replace removes whatever is present at the location, except directories, so that an entry never writes through a link
+// created by a previous entry.
+func replace(location string) error {
+	info, err := os.Lstat(location)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s: a directory is in the way", location)
+	}
+	return os.Remove(location)
+}
+
+func (e *extractor) directory(name string, mode os.FileMode) error {
+	location, err := e.path(name)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(location)
+	if err == nil && !info.IsDir() {
+		return fmt.Errorf("%s: a file is in the way", name)
+	}
+	if err := os.MkdirAll(location, 0755); err != nil {
+		return err
+	}
+	// Directories stay writable by their owner so that their entries can be extracted
+	return os.Chmod(location, mode.Perm()|0700)
+}
+
+func (e *extractor) file(name string, mode os.FileMode, content io.Reader) error {
+	location, err := e.path(name)
+	if err != nil {
+		return err
+	}
+	if err := replace(location); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(location, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	// The mode given when creating the file is subject to the umask
+	return os.Chmod(location, mode.Perm())
+}
+
+func (e *extractor) symlink(name string, target string) error {
+	location, err := e.path(name)
+	if err != nil {
+		return err
+	}
+
+	if target == "" || filepath.IsAbs(target) || strings.HasPrefix(target, "/") {
+		return fmt.Errorf("%s: illegal link target %s", name, target)
+	}
+	if _, err := e.resolve(filepath.Dir(location), target); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	if err := replace(location); err != nil {
+		return err
+	}
+	return os.Symlink(target, location)
+}
+
+func (e *extractor) hardLink(name string, target string) error {
+	location, err := e.path(name)
+	if err != nil {
+		return err
+	}
+
+	targetPath, err := e.resolve(e.root, target)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	info, err := os.Lstat(targetPath)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s: hard link target %s is not a regular file", name, target)
+	}
+
+	if err := replace(location); err != nil {
+		return err
+	}
+	return os.Link(targetPath, location)
+}
+
+// resolve follows the target from the directory, resolving the links already extracted along the way, and fails as
+// soon as it leaves the destination directory.
+func (e *extractor) resolve(dir string, target string) (string, error) {
+	current := dir
+	for _, element := range strings.Split(filepath.ToSlash(target), "/") {
+		switch element {
+		case "", ".":
+			continue
+		case "..":
+			current = filepath.Dir(current)
+		default:
+			current = filepath.Join(current, element)
+			if real, err := filepath.EvalSymlinks(current); err == nil {
+				current = real
+			}
+		}
+
+		if !e.contains(current) {
+			return "", fmt.Errorf("link target %s is outside of the destination directory", target)
+		}
+	}
+	return current, nil
+}
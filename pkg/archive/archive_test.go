@@ -0,0 +1,296 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+type entry struct {
+	name     string
+	typeflag byte
+	mode     int64
+	content  string
+	linkname string
+}
+
+func file(name string, mode int64, content string) entry {
+	return entry{name: name, typeflag: tar.TypeReg, mode: mode, content: content}
+}
+
+func symlink(name string, target string) entry {
+	return entry{name: name, typeflag: tar.TypeSymlink, mode: 0777, linkname: target}
+}
+
+func buildTar(t testing.TB, entries ...entry) []byte {
+	var buffer bytes.Buffer
+	writer := tar.NewWriter(&buffer)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Typeflag: e.typeflag, Mode: e.mode, Linkname: e.linkname, Size: int64(len(e.content))}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func compress(t testing.TB, format Format, content []byte) []byte {
+	var buffer bytes.Buffer
+	var writer io.WriteCloser
+	var err error
+
+	switch format {
+	case TarGz:
+		writer = gzip.NewWriter(&buffer)
+	case TarXz:
+		writer, err = xz.NewWriter(&buffer)
+	case TarZstd:
+		writer, err = zstd.NewWriter(&buffer)
+	default:
+		return content
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func buildZip(t testing.TB) []byte {
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+
+	header := &zip.FileHeader{Name: "pkg/bin/tool"}
+	header.SetMode(0750)
+	w, err := writer.CreateHeader(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("tool"))
+
+	header = &zip.FileHeader{Name: "pkg/tool"}
+	header.SetMode(os.ModeSymlink | 0777)
+	w, err = writer.CreateHeader(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("bin/tool"))
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func write(t testing.TB, content []byte) string {
+	archive := filepath.Join(t.TempDir(), "archive")
+	if err := os.WriteFile(archive, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return archive
+}
+
+func assertFile(t *testing.T, file string, content string, mode os.FileMode) {
+	t.Helper()
+	actual, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(actual) != content {
+		t.Errorf("%s contains %q, expected %q", file, actual, content)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != mode {
+		t.Errorf("%s has mode %s, expected %s", file, info.Mode().Perm(), mode)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	content := buildTar(t, file("tool", 0755, "tool"))
+	tests := map[Format][]byte{
+		Tar:     content,
+		TarGz:   compress(t, TarGz, content),
+		TarXz:   compress(t, TarXz, content),
+		TarZstd: compress(t, TarZstd, content),
+		Zip:     buildZip(t),
+		Raw:     []byte("#!/bin/sh\necho tool\n"),
+	}
+
+	for expected, content := range tests {
+		actual, err := DetectFormat(write(t, content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Errorf("DetectFormat() = %s, expected %s", actual, expected)
+		}
+	}
+
+	actual, err := DetectFormat("testdata/file.tar.bz2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual != TarBz2 {
+		t.Errorf("DetectFormat() = %s, expected %s", actual, TarBz2)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	content := buildTar(t,
+		entry{name: "pkg/", typeflag: tar.TypeDir, mode: 0755},
+		file("pkg/bin/tool", 0750, "tool"),
+		file("pkg/README", 0644, "readme"),
+		symlink("pkg/tool", "bin/tool"),
+		entry{name: "pkg/bin/alias", typeflag: tar.TypeLink, linkname: "pkg/bin/tool"},
+	)
+
+	for _, format := range []Format{Tar, TarGz, TarXz, TarZstd} {
+		destination := t.TempDir()
+		if err := Extract(write(t, compress(t, format, content)), destination, Options{}); err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+
+		assertFile(t, filepath.Join(destination, "pkg", "bin", "tool"), "tool", 0750)
+		assertFile(t, filepath.Join(destination, "pkg", "README"), "readme", 0644)
+		assertFile(t, filepath.Join(destination, "pkg", "tool"), "tool", 0750)
+		assertFile(t, filepath.Join(destination, "pkg", "bin", "alias"), "tool", 0750)
+
+		if target, err := os.Readlink(filepath.Join(destination, "pkg", "tool")); err != nil || target != "bin/tool" {
+			t.Errorf("%s: pkg/tool links to %q (%v), expected bin/tool", format, target, err)
+		}
+	}
+}
+
+func TestExtractBzip2(t *testing.T) {
+	destination := t.TempDir()
+	if err := Extract("testdata/file.tar.bz2", destination, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, filepath.Join(destination, "pkg", "file"), "bzip2 content\n", 0640)
+}
+
+func TestExtractZip(t *testing.T) {
+	destination := t.TempDir()
+	if err := Extract(write(t, buildZip(t)), destination, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, filepath.Join(destination, "pkg", "bin", "tool"), "tool", 0750)
+	assertFile(t, filepath.Join(destination, "pkg", "tool"), "tool", 0750)
+}
+
+func TestExtractStripComponents(t *testing.T) {
+	content := buildTar(t,
+		file("README", 0644, "skipped"),
+		file("./pkg-1.0/bin/tool", 0755, "tool"),
+	)
+
+	destination := t.TempDir()
+	if err := Extract(write(t, content), destination, Options{StripComponents: 1}); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, filepath.Join(destination, "bin", "tool"), "tool", 0755)
+	if _, err := os.Stat(filepath.Join(destination, "README")); !os.IsNotExist(err) {
+		t.Errorf("README should have been skipped")
+	}
+}
+
+func TestExtractRaw(t *testing.T) {
+	err := Extract(write(t, []byte("#!/bin/sh\n")), t.TempDir(), Options{})
+	if !errors.Is(err, ErrNotArchive) {
+		t.Errorf("Extract() = %v, expected %v", err, ErrNotArchive)
+	}
+}
+
+func TestExtractStaysInDestination(t *testing.T) {
+	tests := map[string][]byte{
+		"parent path":       buildTar(t, file("../escaped", 0644, "escaped")),
+		"absolute path":     buildTar(t, file("/escaped", 0644, "escaped")),
+		"absolute link":     buildTar(t, symlink("link", "/tmp")),
+		"escaping link":     buildTar(t, symlink("link", "../.."), file("link/escaped", 0644, "escaped")),
+		"chained links":     buildTar(t, symlink("dir/link", "."), symlink("dir/up", "link/../.."), file("dir/up/escaped", 0644, "escaped")),
+		"hard link outside": buildTar(t, entry{name: "link", typeflag: tar.TypeLink, linkname: "../../etc/hostname"}),
+	}
+
+	for name, content := range tests {
+		parent := t.TempDir()
+		destination := filepath.Join(parent, "destination")
+		if err := os.Mkdir(destination, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		// Errors are fine, as long as nothing is written outside of the destination
+		Extract(write(t, content), destination, Options{})
+
+		entries, err := os.ReadDir(parent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("%s: extraction wrote outside of the destination: %v", name, entries)
+		}
+		filepath.Walk(destination, func(file string, info os.FileInfo, err error) error {
+			if err == nil && info.Mode()&os.ModeSymlink != 0 {
+				if target, err := filepath.EvalSymlinks(file); err == nil && !isInside(target, destination) {
+					t.Errorf("%s: %s links outside of the destination to %s", name, file, target)
+				}
+			}
+			return nil
+		})
+	}
+}
+
+func isInside(file string, dir string) bool {
+	dir, _ = filepath.EvalSymlinks(dir)
+	return file == dir || strings.HasPrefix(file, dir+string(os.PathSeparator))
+}
+
+func FuzzExtract(f *testing.F) {
+	f.Add(buildTar(f, file("pkg/bin/tool", 0755, "tool"), symlink("pkg/tool", "bin/tool")))
+	f.Add(buildTar(f, symlink("link", ".."), file("link/escaped", 0644, "escaped")))
+	f.Add(buildTar(f, entry{name: "link", typeflag: tar.TypeLink, linkname: "../outside"}))
+	f.Add(compress(f, TarGz, buildTar(f, file("../escaped", 0644, "escaped"))))
+	f.Add(buildZip(f))
+
+	f.Fuzz(func(t *testing.T, content []byte) {
+		parent := t.TempDir()
+		destination := filepath.Join(parent, "destination")
+		if err := os.Mkdir(destination, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		Extract(write(t, content), destination, Options{})
+
+		entries, err := os.ReadDir(parent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("extraction wrote outside of the destination: %v", entries)
+		}
+	})
+}
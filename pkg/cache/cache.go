@@ -0,0 +1,401 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	du "io.twasyl/devcore/pkg/utils"
+)
+
+// Cache is a content-addressed store of downloaded files. Files are stored under their SHA-256 digest and indexed by
+// the URL they've been downloaded from:
+//   - <dir>/blobs/sha256/<digest> holds the content of the files
+//   - <dir>/entries/<sha256 of the URL>.json describes the file downloaded from the URL
+//   - <dir>/partial/<sha256 of the URL> holds interrupted downloads, resumed on the next attempt, and
+//     <dir>/partial/<sha256 of the URL>.lock serializes the downloads of the URL
+type Cache struct {
+	Dir string
+	// Offline forbids any download, files can only come from the cache.
+	Offline bool
+}
+
+// Entry describes a cached file.
+type Entry struct {
+	URL      string    `json:"url"`
+	Digest   string    `json:"digest"`
+	Size     int64     `json:"size"`
+	LastUsed time.Time `json:"last-used"`
+}
+
+// NotCached is returned when a file must be downloaded while the cache is offline.
+type NotCached struct {
+	URL string
+}
+
+func (e *NotCached) Error() string {
+	return fmt.Sprintf("%s is not in the cache and can not be downloaded in offline mode", e.URL)
+}
+
+func IsNotCached(err error) bool {
+	var notCached *NotCached
+	return errors.As(err, &notCached)
+}
+
+// Fetch copies the file downloaded from the URL to the destination. The file is taken from the cache when present,
+// either because it has been downloaded from the same URL or because a file having the expected SHA-256 digest is
+// cached. The expected digest is optional. Otherwise the file is downloaded, resuming any interrupted download, and
+// added to the cache.
+func (c *Cache) Fetch(url string, expectedDigest string, destination string) error {
+	entry, err := c.lookup(url, strings.ToLower(expectedDigest))
+	if err != nil {
+		return err
+	}
+
+	if du.DryRun {
+		if entry != nil {
+			du.Plan("copy %s, cached from %s, to %s", c.blob(entry.Digest), url, destination)
+		} else if c.Offline {
+			return &NotCached{URL: url}
+		} else {
+			du.Plan("download %s to the cache and copy it to %s", url, destination)
+		}
+		return nil
+	}
+
+	if entry == nil {
+		if c.Offline {
+			return &NotCached{URL: url}
+		}
+		if entry, err = c.download(url, strings.ToLower(expectedDigest)); err != nil {
+			return err
+		}
+	}
+
+	entry.LastUsed = time.Now()
+	if err := c.writeEntry(*entry); err != nil {
+		return err
+	}
+	return copyFile(c.blob(entry.Digest), destination)
+}
+
+// lookup finds the cache entry of the URL, or else of a file having the expected digest. An invalid entry is ignored,
+// it is replaced once the file is downloaded again.
+func (c *Cache) lookup(url string, expectedDigest string) (*Entry, error) {
+	entry, err := c.readEntry(c.entry(url))
+	var invalid *invalidEntry
+	if err != nil && !os.IsNotExist(err) && !errors.As(err, &invalid) {
+		return nil, err
+	}
+
+	if entry != nil && (expectedDigest == "" || entry.Digest == expectedDigest) {
+		if _, err := os.Stat(c.blob(entry.Digest)); err == nil {
+			return entry, nil
+		}
+	}
+
+	if len(expectedDigest) == sha256.Size*2 {
+		if info, err := os.Stat(c.blob(expectedDigest)); err == nil {
+			return &Entry{URL: url, Digest: expectedDigest, Size: info.Size()}, nil
+		}
+	}
+	return nil, nil
+}
+
+// download downloads the file from the URL and adds it to the cache. The download holds the lock of the URL, so that
+// devcore processes downloading the same file don't write to the same partial download. The file is not downloaded
+// again when another process added it to the cache while waiting for the lock.
+func (c *Cache) download(url string, expectedDigest string) (*Entry, error) {
+	for _, dir := range []string{c.partialDir(), c.blobsDir(), c.entriesDir()} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	partial := filepath.Join(c.partialDir(), key(url))
+	lock, err := du.LockFile(partial+".lock", func() {
+		fmt.Println(fmt.Sprintf("Waiting for another devcore process downloading %s", url))
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	if entry, err := c.lookup(url, expectedDigest); err != nil || entry != nil {
+		return entry, err
+	}
+
+	if err := du.ResumeDownload(url, partial); err != nil {
+		return nil, err
+	}
+
+	digest, err := du.FileChecksum(partial, "sha256")
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(partial)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(partial, c.blob(digest)); err != nil {
+		return nil, err
+	}
+
+	// Written before releasing the lock, for the processes waiting for it to find the file
+	entry := Entry{URL: url, Digest: digest, Size: info.Size(), LastUsed: time.Now()}
+	return &entry, c.writeEntry(entry)
+}
+
+// Evict removes the file downloaded from the URL from the cache, for instance because it didn't pass its verification.
+func (c *Cache) Evict(url string) error {
+	entry, err := c.readEntry(c.entry(url))
+	var invalid *invalidEntry
+	if os.IsNotExist(err) {
+		return nil
+	} else if errors.As(err, &invalid) && !du.DryRun {
+		return os.Remove(c.entry(url))
+	} else if err != nil {
+		return err
+	}
+	if du.DryRun {
+		du.Plan("remove %s from the cache", url)
+		return nil
+	}
+
+	if err := os.Remove(c.blob(entry.Digest)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Remove(c.entry(url))
+}
+
+// Entries lists the cached files, sorted by URL. Invalid entries, e.g. written by a devcore process that crashed, are
+// skipped with a warning.
+func (c *Cache) Entries() ([]Entry, error) {
+	files, err := os.ReadDir(c.entriesDir())
+	if os.IsNotExist(err) {
+		return []Entry{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0)
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), ".") {
+			// Entry being written
+			continue
+		}
+
+		entry, err := c.readEntry(filepath.Join(c.entriesDir(), file.Name()))
+		var invalid *invalidEntry
+		if errors.As(err, &invalid) {
+			fmt.Fprintln(os.Stderr, fmt.Sprintf("Warning: %s, it is skipped", err))
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].URL < entries[j].URL
+	})
+	return entries, nil
+}
+
+// Prune removes the files not used since the given time, as well as interrupted downloads. The downloads in progress,
+// whose lock is held by another process, are kept. The pruned entries are returned.
+func (c *Cache) Prune(unusedSince time.Time) ([]Entry, error) {
+	entries, err := c.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	pruned := make([]Entry, 0)
+	inUse := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.LastUsed.Before(unusedSince) && du.DryRun {
+			du.Plan("remove %s from the cache", entry.URL)
+			pruned = append(pruned, entry)
+		} else if entry.LastUsed.Before(unusedSince) {
+			if err := os.Remove(c.entry(entry.URL)); err != nil {
+				return nil, err
+			}
+			pruned = append(pruned, entry)
+		} else {
+			inUse[entry.Digest] = true
+		}
+	}
+
+	if du.DryRun {
+		du.Plan("remove the unused files of %s and %s", c.blobsDir(), c.partialDir())
+		return pruned, nil
+	}
+
+	blobs, err := os.ReadDir(c.blobsDir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, blob := range blobs {
+		if !inUse[blob.Name()] {
+			if err := os.Remove(filepath.Join(c.blobsDir(), blob.Name())); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := c.prunePartialDownloads(); err != nil {
+		return nil, err
+	}
+	return pruned, nil
+}
+
+// prunePartialDownloads removes the interrupted downloads. A partial download is only removed while holding its lock,
+// the lock files are kept so that every process locks the same file.
+func (c *Cache) prunePartialDownloads() error {
+	partials, err := os.ReadDir(c.partialDir())
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, partial := range partials {
+		if strings.HasSuffix(partial.Name(), ".lock") {
+			continue
+		}
+
+		file := filepath.Join(c.partialDir(), partial.Name())
+		lock, err := du.TryLockFile(file + ".lock")
+		if err != nil {
+			return err
+		} else if lock == nil {
+			// Being downloaded
+			continue
+		}
+
+		err = os.Remove(file)
+		lock.Unlock()
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Cache) readEntry(file string) (*Entry, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := Entry{}
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return nil, &invalidEntry{File: file, Err: err}
+	}
+	if len(entry.Digest) != sha256.Size*2 {
+		return nil, &invalidEntry{File: file, Err: fmt.Errorf("invalid digest %q", entry.Digest)}
+	}
+	return &entry, nil
+}
+
+// writeEntry replaces the entry atomically, so that it is never found partially written.
+func (c *Cache) writeEntry(entry Entry) error {
+	if err := os.MkdirAll(c.entriesDir(), 0755); err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	file := c.entry(entry.URL)
+	temporary, err := os.CreateTemp(c.entriesDir(), "."+filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary.Name())
+
+	_, err = temporary.Write(content)
+	if err == nil {
+		err = temporary.Chmod(0644)
+	}
+	if err == nil {
+		err = temporary.Sync()
+	}
+	if closeErr := temporary.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(temporary.Name(), file)
+}
+
+// invalidEntry is returned when an entry can not be read.
+type invalidEntry struct {
+	File string
+	Err  error
+}
+
+func (e *invalidEntry) Error() string {
+	return fmt.Sprintf("invalid cache entry %s: %s", e.File, e.Err)
+}
+
+func (e *invalidEntry) Unwrap() error {
+	return e.Err
+}
+
+func (c *Cache) blobsDir() string {
+	return filepath.Join(c.Dir, "blobs", "sha256")
+}
+
+func (c *Cache) blob(digest string) string {
+	return filepath.Join(c.blobsDir(), digest)
+}
+
+func (c *Cache) entriesDir() string {
+	return filepath.Join(c.Dir, "entries")
+}
+
+func (c *Cache) entry(url string) string {
+	return filepath.Join(c.entriesDir(), key(url)+".json")
+}
+
+func (c *Cache) partialDir() string {
+	return filepath.Join(c.Dir, "partial")
+}
+
+func key(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+func copyFile(source string, destination string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
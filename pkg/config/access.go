@@ -0,0 +1,228 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Values returns the configuration as generic JSON values, the way it is written in the configuration file.
+func (c *DevCoreConfig) Values() (map[string]interface{}, error) {
+	content, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	err = json.Unmarshal(content, &values)
+	return values, err
+}
+
+// Get returns the value at the dotted path, like jenkins.current-context. Elements of lists are designated by their
+// index or, for contexts, by their name, like jenkins.contexts.my-context.war.
+func (c *DevCoreConfig) Get(path string) (interface{}, error) {
+	values, err := c.Values()
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{} = values
+	for _, key := range strings.Split(path, ".") {
+		value, _, err = child(value, key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return value, nil
+}
+
+// Set changes the value at the dotted path. The raw value is parsed as the type of the current value: a number, a
+// boolean, or JSON for lists and objects. It is taken as a string otherwise. The configuration is left untouched when
+// the path doesn't exist in the configuration's structure or the value doesn't fit it.
+func (c *DevCoreConfig) Set(path string, raw string) error {
+	current, err := c.Get(path)
+	exists := err == nil
+
+	value, err := parseValue(raw, current)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	err = c.setValue(path, value)
+	if err != nil && !exists && value != raw {
+		// Values not set yet have no type to follow, a string may have been meant
+		err = c.setValue(path, raw)
+	}
+	return err
+}
+
+// setValue changes the value at the dotted path to a JSON value.
+func (c *DevCoreConfig) setValue(path string, value interface{}) error {
+	values, err := c.Values()
+	if err != nil {
+		return err
+	}
+
+	keys := strings.Split(path, ".")
+	var parent interface{} = values
+	for _, key := range keys[:len(keys)-1] {
+		parent, _, err = child(parent, key)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	last := keys[len(keys)-1]
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		container[last] = value
+	case []interface{}:
+		index, err := elementIndex(container, last)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		container[index] = value
+	default:
+		return fmt.Errorf("%s: %s can not hold keys", path, strings.Join(keys[:len(keys)-1], "."))
+	}
+
+	updated, err := Decode(values)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	*c = updated
+	return nil
+}
+
+// Decode builds a configuration from generic JSON values, rejecting the keys the configuration doesn't have.
+func Decode(values interface{}) (DevCoreConfig, error) {
+	content, err := json.Marshal(values)
+	if err != nil {
+		return DevCoreConfig{}, err
+	}
+	return Parse(content)
+}
+
+// Parse parses the content of a configuration file, rejecting the keys the configuration doesn't have and the values
+// preventing it from being loaded.
+func Parse(content []byte) (DevCoreConfig, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+
+	parsed := DevCoreConfig{}
+	if err := decoder.Decode(&parsed); err != nil {
+		return DevCoreConfig{}, err
+	}
+
+	if parsed.Network.Timeout != "" {
+		if _, err := time.ParseDuration(parsed.Network.Timeout); err != nil {
+			return DevCoreConfig{}, fmt.Errorf("invalid network timeout %s: %w", parsed.Network.Timeout, err)
+		}
+	}
+	return parsed, nil
+}
+
+// secretMask replaces the values of the secrets when the configuration is displayed.
+const secretMask = "********"
+
+// IsSecret tells if the setting at the dotted path holds a secret, like releases.github-token: its key is token or ends
+// with -token.
+func IsSecret(path string) bool {
+	key := path[strings.LastIndex(path, ".")+1:]
+	return key == "token" || strings.HasSuffix(key, "-token")
+}
+
+// MaskSecrets returns a copy of the generic JSON value found at the dotted path, in which the secrets it holds, at any
+// depth, are masked. Secrets not set are left empty.
+func MaskSecrets(value interface{}, path string) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		masked := make(map[string]interface{})
+		for key, child := range value {
+			masked[key] = MaskSecrets(child, join(path, key))
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, 0)
+		for index, element := range value {
+			masked = append(masked, MaskSecrets(element, join(path, fmt.Sprint(index))))
+		}
+		return masked
+	case string:
+		if value != "" && IsSecret(path) {
+			return secretMask
+		}
+	}
+	return value
+}
+
+// WithoutSecrets returns a copy of the configuration, to be displayed, in which the secrets are masked.
+func (c *DevCoreConfig) WithoutSecrets() (DevCoreConfig, error) {
+	values, err := c.Values()
+	if err != nil {
+		return DevCoreConfig{}, err
+	}
+	return Decode(MaskSecrets(values, ""))
+}
+
+// child returns the value of the key in a JSON object or list. The returned boolean tells if the key designates an
+// existing value.
+func child(value interface{}, key string) (interface{}, bool, error) {
+	switch container := value.(type) {
+	case map[string]interface{}:
+		child, exists := container[key]
+		if !exists {
+			return nil, false, fmt.Errorf("no %s key", key)
+		}
+		return child, true, nil
+	case []interface{}:
+		index, err := elementIndex(container, key)
+		if err != nil {
+			return nil, false, err
+		}
+		return container[index], true, nil
+	}
+	return nil, false, fmt.Errorf("no %s key in a value which is not an object", key)
+}
+
+// elementIndex returns the index of the element designated by the key in a list: either the index itself or the name
+// of the element.
+func elementIndex(list []interface{}, key string) (int, error) {
+	if index, err := strconv.Atoi(key); err == nil {
+		if index < 0 || index >= len(list) {
+			return 0, fmt.Errorf("index %d out of range", index)
+		}
+		return index, nil
+	}
+
+	for index, element := range list {
+		if object, ok := element.(map[string]interface{}); ok && object["name"] == key {
+			return index, nil
+		}
+	}
+	return 0, fmt.Errorf("no element named %s", key)
+}
+
+func parseValue(raw string, current interface{}) (interface{}, error) {
+	switch current.(type) {
+	case float64:
+		return strconv.ParseFloat(raw, 64)
+	case bool:
+		return strconv.ParseBool(raw)
+	case []interface{}, map[string]interface{}:
+		var value interface{}
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			return nil, fmt.Errorf("expecting JSON: %w", err)
+		}
+		return value, nil
+	case nil:
+		var value interface{}
+		if err := json.Unmarshal([]byte(raw), &value); err == nil {
+			return value, nil
+		}
+	}
+	return raw, nil
+}
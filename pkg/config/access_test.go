@@ -0,0 +1,29 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMaskSecrets(t *testing.T) {
+	values := map[string]interface{}{
+		"releases": map[string]interface{}{"github-api-url": "https://api.github.com", "github-token": "ghp_x1"},
+		"network":  map[string]interface{}{"mirrors": []interface{}{map[string]interface{}{"token": "s3cr3t"}}},
+		"plugins":  map[string]interface{}{"registry-token": ""},
+	}
+	expected := map[string]interface{}{
+		"releases": map[string]interface{}{"github-api-url": "https://api.github.com", "github-token": secretMask},
+		"network":  map[string]interface{}{"mirrors": []interface{}{map[string]interface{}{"token": secretMask}}},
+		"plugins":  map[string]interface{}{"registry-token": ""},
+	}
+
+	if masked := MaskSecrets(values, ""); !reflect.DeepEqual(masked, expected) {
+		t.Errorf("Expected %v, got %v", expected, masked)
+	}
+	if masked := MaskSecrets("ghp_x1", "releases.github-token"); masked != secretMask {
+		t.Errorf("Expected the token to be masked, got %v", masked)
+	}
+	if values["releases"].(map[string]interface{})["github-token"] != "ghp_x1" {
+		t.Error("Expected the values to be left untouched")
+	}
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed tools.yaml
+var defaultToolsManifest []byte
+
+// ToolManifest is the content of a file declaring tools that can be installed by devcore. Manifests are written in
+// YAML or JSON.
+type ToolManifest struct {
+	Tools []Tool `json:"tools" yaml:"tools"`
+}
+
+func parseToolManifest(name string, content []byte) ([]Tool, error) {
+	manifest := ToolManifest{}
+
+	var err error
+	if strings.HasSuffix(name, ".json") {
+		err = json.Unmarshal(content, &manifest)
+	} else {
+		err = yaml.Unmarshal(content, &manifest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can not parse tool manifest %s: %w", name, err)
+	}
+
+	for _, tool := range manifest.Tools {
+		if err := tool.validate(); err != nil {
+			return nil, fmt.Errorf("invalid tool manifest %s: %w", name, err)
+		}
+	}
+	return manifest.Tools, nil
+}
+
+func readToolManifest(file string) ([]Tool, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return parseToolManifest(file, content)
+}
+
+// toolManifestFiles lists the manifests dropped in the tools.d directory followed by the catalogs referenced in the
+// configuration.
+func (c *DevCoreConfig) toolManifestFiles() ([]string, error) {
+	files := make([]string, 0)
+
+	entries, err := os.ReadDir(toolsManifestsDir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		extension := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (extension == ".yaml" || extension == ".yml" || extension == ".json") {
+			files = append(files, filepath.Join(toolsManifestsDir(), entry.Name()))
+		}
+	}
+	sort.Strings(files)
+
+	return append(files, c.ToolCatalogs...), nil
+}
+
+// loadToolCatalogs builds the SupportedTools from the DefaultSupportedTools and the user's manifests. A tool declared
+// in a manifest replaces any previously declared tool having the same name.
+func (c *DevCoreConfig) loadToolCatalogs() error {
+	files, err := c.toolManifestFiles()
+	if err != nil {
+		return err
+	}
+
+	tools := append([]Tool{}, DefaultSupportedTools...)
+	for _, file := range files {
+		declared, err := readToolManifest(file)
+		if err != nil {
+			return err
+		}
+
+		for _, tool := range declared {
+			replaced := false
+			for index := range tools {
+				if tools[index].Name == tool.Name {
+					tools[index] = tool
+					replaced = true
+				}
+			}
+			if !replaced {
+				tools = append(tools, tool)
+			}
+		}
+	}
+
+	sort.Slice(tools, func(i, j int) bool {
+		return tools[i].Name < tools[j].Name
+	})
+	SupportedTools = tools
+	return nil
+}
+
+func toolsManifestsDir() string {
+	return filepath.Join(configDir(), "tools.d")
+}
@@ -3,20 +3,32 @@
 package config
 
 import (
-	"bytes"
-	"encoding/json"
-	"log"
 	"os"
 	"path/filepath"
+	"reflect"
+
+	"io.twasyl/devcore/pkg/cache"
+	du "io.twasyl/devcore/pkg/utils"
 )
 
 // DevCoreConfig represents the configuration of the CLI
 type DevCoreConfig struct {
+	// Version is the version of the configuration's structure, used to migrate the files written by older versions
+	Version             int               `json:"version"`
 	DockerCompose       DockerCompose     `json:"docker-compose"`
 	DefaultToolsVersion map[string]string `json:"default-tools-version"`
 	ProjectsDir         string            `json:"projects-dir"`
 	ServersDir          string            `json:"servers-dir"`
 	Jenkins             Jenkins           `json:"jenkins"`
+	ToolCatalogs        []string          `json:"tool-catalogs"`
+	TeamConfigs         []string          `json:"team-configs,omitempty"`
+	Verification        Verification      `json:"verification"`
+	InstallPrefix       string            `json:"install-prefix"`
+	Releases            Releases          `json:"releases"`
+	Network             Network           `json:"network"`
+	JDK                 JDK               `json:"jdk"`
+	CurrentProfile      string            `json:"current-profile,omitempty"`
+	Profiles            []Profile         `json:"profiles,omitempty"`
 }
 
 type DockerCompose struct {
@@ -29,8 +41,20 @@ type DockerComposeContext struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	File        string `json:"file"`
+	// StopPolicy tells what `docker-compose context stop` does: stop the containers, by default, or remove them.
+	StopPolicy string `json:"stop-policy,omitempty"`
 }
 
+const (
+	// StopPolicyStop stops the containers of a context, keeping them and their volumes.
+	StopPolicyStop = "stop"
+	// StopPolicyDown removes the containers of a context, keeping their volumes.
+	StopPolicyDown = "down"
+)
+
+// StopPolicies are the valid stop policies of the docker compose contexts.
+var StopPolicies = []string{StopPolicyStop, StopPolicyDown}
+
 // Jenkins describes the configuration of the Jenkins command
 type Jenkins struct {
 	Cli            string           `json:"cli"`
@@ -51,16 +75,39 @@ type JenkinsContext struct {
 
 var Config = DevCoreConfig{}
 
-// Load loads the CLI configuration into the Config struct.
+// Offline forbids downloads, artifacts are only taken from the download cache.
+var Offline = false
+
+// Load loads the CLI configuration into the Config struct, from its layers, overlaid with the active profile. The
+// settings can be overridden by DEVCORE_<KEY> environment variables, like DEVCORE_PROJECTS_DIR.
 func Load() error {
-	ensureConfigFileSystemElements()
-	file, err := os.Open(configFile())
+	dir, err := resolveHomeDir()
+	if err != nil {
+		return err
+	}
+	homeDir = dir
+
+	loadedFile, err = resolveConfigFile()
+	if err != nil {
+		return err
+	}
+
+	err = ensureConfigFileSystemElements()
+	if err != nil {
+		return err
+	}
+
+	user, userValues, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	err = loadLayers(user, userValues)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	err = json.NewDecoder(file).Decode(&Config)
+	err = Config.loadToolCatalogs()
 	if err != nil {
 		return err
 	}
@@ -69,7 +116,25 @@ func Load() error {
 	Config.fillDefaultProjectsDir()
 	Config.fillDefaultServersDir()
 
-	return nil
+	// The defaults are not saved, so that the layers can still set them
+	loadedValues, err = Config.Values()
+	if err != nil {
+		return err
+	}
+
+	Config.activateProfile()
+
+	err = Config.applyOverrides()
+	if err != nil {
+		return err
+	}
+
+	unchangedValues, err = Config.Values()
+	if err != nil {
+		return err
+	}
+
+	return Config.Network.apply(Config.Releases.tokens())
 }
 
 func (c *DevCoreConfig) fillDefaultToolsVersion() {
@@ -77,7 +142,7 @@ func (c *DevCoreConfig) fillDefaultToolsVersion() {
 		c.DefaultToolsVersion = make(map[string]string)
 	}
 
-	for _, tool := range DefaultSupportedTools {
+	for _, tool := range SupportedTools {
 		if _, exists := c.DefaultToolsVersion[tool.Name]; !exists {
 			c.DefaultToolsVersion[tool.Name] = tool.DefaultVersion
 		}
@@ -98,6 +163,28 @@ func (c *DevCoreConfig) fillDefaultServersDir() {
 	}
 }
 
+// Prefix returns the directory under which tools are installed. Unless configured, it is /usr/local for root and the
+// devcore directory of the user otherwise, so that tools can be installed without elevated privileges.
+func (c *DevCoreConfig) Prefix() string {
+	if c.InstallPrefix != "" {
+		return c.InstallPrefix
+	} else if os.Geteuid() == 0 {
+		return "/usr/local"
+	} else {
+		return configDir()
+	}
+}
+
+// Cache returns the cache of downloaded artifacts.
+func (c *DevCoreConfig) Cache() *cache.Cache {
+	return &cache.Cache{Dir: filepath.Join(configDir(), "cache"), Offline: Offline}
+}
+
+// BinDir returns the directory where the binaries of the tools are installed.
+func (c *DevCoreConfig) BinDir() string {
+	return filepath.Join(c.Prefix(), "bin")
+}
+
 // FindContextByName looks in the config for a DockerComposeContext named with the desired one.
 func (c *DockerCompose) FindContextByName(name string) (DockerComposeContext, error) {
 	for _, context := range c.Contexts {
@@ -130,6 +217,24 @@ func (c *DockerComposeContext) Dir() string {
 	return filepath.Dir(c.File)
 }
 
+// StopVerb returns the docker compose command stopping the context according to its stop policy.
+func (c *DockerComposeContext) StopVerb() string {
+	if c.StopPolicy == "" {
+		return StopPolicyStop
+	}
+	return c.StopPolicy
+}
+
+// IsStopPolicy tells if the policy is a valid stop policy, the empty one being the default.
+func IsStopPolicy(policy string) bool {
+	for _, valid := range StopPolicies {
+		if policy == valid {
+			return true
+		}
+	}
+	return policy == ""
+}
+
 // FindContextByName looks in the config for a DockerComposeContext named with the desired one.
 func (j *Jenkins) FindContextByName(name string) (JenkinsContext, error) {
 	for _, context := range j.Contexts {
@@ -171,50 +276,35 @@ func (j *Jenkins) UpdateContext(c JenkinsContext) error {
 	return &JenkinsContextNotFound{c.Name}
 }
 
-// Save will save the CLI configuration to the file system.
+// Save will save the CLI configuration to the file system. Only the changes made since the configuration has been
+// loaded are written, on top of the ones other devcore processes may have saved meanwhile. The settings overridden by
+// environment variables are saved with the value they have in the configuration file, the changes made to the settings
+// of the active profile are saved in the profile. Nothing is written when the configuration has not changed.
 func Save() error {
-	ensureConfigFileSystemElements()
-	_, err := os.Stat(configFile())
+	values, err := Config.Values()
 	if err != nil {
 		return err
 	}
-
-	buffer := bytes.Buffer{}
-	err = json.NewEncoder(&buffer).Encode(Config)
-	if err != nil {
-		return err
+	if reflect.DeepEqual(values, unchangedValues) {
+		return nil
 	}
-	return os.WriteFile(configFile(), buffer.Bytes(), 0755)
-}
 
-func ensureConfigFileSystemElements() {
-	if _, err := os.Stat(configDir()); os.IsNotExist(err) {
-		err := os.Mkdir(configDir(), 0755)
-		if err != nil {
-			log.Fatal("Can not create configuration directory", err)
+	// In dry run mode, the creation of the file is planned when loading the configuration
+	if !du.DryRun {
+		if err := ensureConfigFileSystemElements(); err != nil {
+			return err
 		}
 	}
 
-	if _, err := os.Stat(configFile()); os.IsNotExist(err) {
-		file, err := os.Create(configFile())
-		if err != nil {
-			log.Fatal("Can not create configuration file", err)
-		} else {
-			defer file.Close()
-			file.Write([]byte("{}"))
-		}
+	saved, err := Config.withoutOverrides()
+	if err != nil {
+		return err
 	}
-}
 
-func configDir() string {
-	if homeDir, err := os.UserHomeDir(); err != nil {
-		log.Fatal("Can not determine user home dir", err)
-		return ""
-	} else {
-		return filepath.Join(homeDir, ".devcore")
+	base := saved.withoutProfile()
+	if err := saveConfig(&base); err != nil {
+		return err
 	}
-}
-
-func configFile() string {
-	return filepath.Join(configDir(), "config.json")
+	unchangedValues = values
+	return nil
 }
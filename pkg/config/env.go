@@ -0,0 +1,155 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// managedEnvVariable lists the variables set by `devcore env`, so that they are unset once devcore no longer sets them,
+// e.g. when leaving a project pinning a tool.
+const managedEnvVariable = "DEVCORE_ENV"
+
+// EnvVariable is a variable of the environment set up by `devcore env`. Unset variables are removed from the
+// environment.
+type EnvVariable struct {
+	Name  string
+	Value string
+	Unset bool
+}
+
+// Environment returns the variables setting up a shell working in the given directory:
+//   - the PATH, giving access to the tools installed by devcore, the versions pinned for the directory first
+//   - the homes of the tools declaring a home-env, e.g. MAVEN_HOME, and JAVA_HOME of the active JDK
+//   - JAVA_HOME and JENKINS_HOME of the current Jenkins context, its JAVA_HOME taking precedence
+//   - the names of the current contexts and COMPOSE_FILE of the current docker compose context
+//
+// The variables set by a previous evaluation which are no longer set are unset.
+func Environment(dir string) ([]EnvVariable, error) {
+	tools := append([]Tool{JDKTool()}, SupportedTools...)
+	pins, err := pinnedTools(dir, tools)
+	if err != nil {
+		return nil, err
+	}
+
+	path := []string{}
+	for _, tool := range tools {
+		if version, pinned := pins[tool.Name]; pinned && tool.IsInstalled(version, CurrentPlatform()) {
+			if binary, err := tool.BinaryPath(version); err == nil {
+				path = append(path, filepath.Dir(binary))
+			}
+		}
+	}
+	path = append(path, Config.BinDir())
+	path = append(path, unmanagedPath()...)
+
+	variables := []EnvVariable{{Name: "PATH", Value: strings.Join(path, string(os.PathListSeparator))}}
+	set := func(name string, value string) {
+		if value == "" {
+			return
+		}
+		for index := range variables {
+			if variables[index].Name == name {
+				variables[index].Value = value
+				return
+			}
+		}
+		variables = append(variables, EnvVariable{Name: name, Value: value})
+	}
+
+	for _, tool := range tools {
+		if tool.HomeEnv == "" {
+			continue
+		}
+		version, pinned := pins[tool.Name]
+		if !pinned {
+			version = tool.ActiveVersion()
+		}
+		if version != "" && tool.IsInstalled(version, CurrentPlatform()) {
+			set(tool.HomeEnv, tool.VersionDir(version))
+		}
+	}
+
+	set("DEVCORE_JENKINS_CONTEXT", Config.Jenkins.CurrentContext)
+	if context, err := Config.Jenkins.FindContextByName(Config.Jenkins.CurrentContext); err == nil {
+		set("JAVA_HOME", context.JavaHome)
+		set("JENKINS_HOME", context.JenkinsHome)
+	}
+
+	set("DEVCORE_COMPOSE_CONTEXT", Config.DockerCompose.CurrentContext)
+	if context, err := Config.DockerCompose.FindContextByName(Config.DockerCompose.CurrentContext); err == nil {
+		set("COMPOSE_FILE", context.File)
+	}
+
+	return withUnsetVariables(variables), nil
+}
+
+// pinnedTools returns the versions of the tools pinned for the directory, keyed by tool name. Versions pinned by
+// constraint, like 1.24.x, or by feature version for the JDK, like 17, are resolved to the newest installed version
+// matching them.
+func pinnedTools(dir string, tools []Tool) (map[string]string, error) {
+	pins := make(map[string]string)
+
+	file, err := FindToolVersionsFile(dir)
+	if err != nil || file == "" {
+		return pins, err
+	}
+
+	pinned, err := ReadToolVersions(file)
+	if err != nil {
+		return nil, err
+	}
+	for _, pin := range pinned {
+		pins[pin.Name] = pin.Version
+		for _, tool := range tools {
+			if tool.Name != pin.Name {
+				continue
+			}
+			if installed, err := tool.InstalledPinnedVersion(pin.Version); err == nil {
+				pins[pin.Name] = installed
+			}
+		}
+	}
+	return pins, nil
+}
+
+// unmanagedPath returns the directories of the PATH not added by devcore.
+func unmanagedPath() []string {
+	toolsDir := filepath.Join(Config.Prefix(), "tools") + string(os.PathSeparator)
+
+	dirs := []string{}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir != Config.BinDir() && !strings.HasPrefix(dir, toolsDir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// withUnsetVariables adds the variables to unset, because they were set by the previous evaluation but are no longer,
+// and records the variables now set.
+func withUnsetVariables(variables []EnvVariable) []EnvVariable {
+	managed := []string{}
+	isSet := make(map[string]bool)
+	for _, variable := range variables {
+		if variable.Name == "PATH" {
+			continue
+		}
+		managed = append(managed, variable.Name)
+		isSet[variable.Name] = true
+	}
+
+	for _, name := range strings.Split(os.Getenv(managedEnvVariable), ",") {
+		if name != "" && !isSet[name] {
+			variables = append(variables, EnvVariable{Name: name, Unset: true})
+		}
+	}
+
+	if len(managed) == 0 {
+		if os.Getenv(managedEnvVariable) != "" {
+			variables = append(variables, EnvVariable{Name: managedEnvVariable, Unset: true})
+		}
+		return variables
+	}
+	return append(variables, EnvVariable{Name: managedEnvVariable, Value: strings.Join(managed, ",")})
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnvironmentWithJDKPinnedByFeatureVersion(t *testing.T) {
+	previous := Config
+	defer func() {
+		Config = previous
+	}()
+
+	prefix := t.TempDir()
+	Config = DevCoreConfig{InstallPrefix: prefix, ProjectsDir: filepath.Join(prefix, "projects")}
+	for _, version := range []string{"11.0.20+8", "17.0.7+7", "17.0.8+7"} {
+		if err := os.MkdirAll(filepath.Join(prefix, "tools", "jdk", version, "bin"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	project := filepath.Join(Config.ProjectsDir, "api")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, ToolVersionsFile), []byte("jdk 17\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	variables, err := Environment(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	javaHome := filepath.Join(prefix, "tools", "jdk", "17.0.8+7")
+	found := make(map[string]string)
+	for _, variable := range variables {
+		found[variable.Name] = variable.Value
+	}
+	if found["JAVA_HOME"] != javaHome {
+		t.Errorf("Expected JAVA_HOME to be %s, got %s", javaHome, found["JAVA_HOME"])
+	}
+	if path := filepath.SplitList(found["PATH"]); path[0] != filepath.Join(javaHome, "bin") {
+		t.Errorf("Expected the PATH to start with the pinned JDK, got %s", found["PATH"])
+	}
+}
+
+func TestEnvironmentWithToolPinnedByRange(t *testing.T) {
+	previous := Config
+	defer func() {
+		Config = previous
+	}()
+
+	prefix := t.TempDir()
+	Config = DevCoreConfig{InstallPrefix: prefix, ProjectsDir: filepath.Join(prefix, "projects")}
+	for _, version := range []string{"1.23.6", "1.24.1", "1.24.3"} {
+		if err := os.MkdirAll(filepath.Join(prefix, "tools", "kubectl", version), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	project := filepath.Join(Config.ProjectsDir, "api")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pin := range []string{"1.24.x", "~1.24"} {
+		if err := os.WriteFile(filepath.Join(project, ToolVersionsFile), []byte("kubectl "+pin+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		variables, err := Environment(project)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		kubectlDir := filepath.Join(prefix, "tools", "kubectl", "1.24.3")
+		if path := filepath.SplitList(variables[0].Value); path[0] != kubectlDir {
+			t.Errorf("Expected the PATH to start with %s for kubectl %s, got %s", kubectlDir, pin, variables[0].Value)
+		}
+	}
+}
@@ -65,3 +65,53 @@ func IsServerNotFound(err error) bool {
 	}
 	return false
 }
+
+type ToolVersionNotInstalled struct {
+	Name    string
+	Version string
+}
+
+func (e *ToolVersionNotInstalled) Error() string {
+	return fmt.Sprintf("Version %s of tool '%s' is not installed", e.Version, e.Name)
+}
+
+func IsToolVersionNotInstalled(err error) bool {
+	if err != nil {
+		_, yes := err.(*ToolVersionNotInstalled)
+		return yes
+	}
+	return false
+}
+
+type ToolPlatformNotSupported struct {
+	Name     string
+	Platform string
+}
+
+func (e *ToolPlatformNotSupported) Error() string {
+	return fmt.Sprintf("Tool '%s' is not available for %s", e.Name, e.Platform)
+}
+
+func IsToolPlatformNotSupported(err error) bool {
+	if err != nil {
+		_, yes := err.(*ToolPlatformNotSupported)
+		return yes
+	}
+	return false
+}
+
+type ProfileNotFound struct {
+	Name string
+}
+
+func (e *ProfileNotFound) Error() string {
+	return fmt.Sprintf("Profile not found: '%s'", e.Name)
+}
+
+func IsProfileNotFound(err error) bool {
+	if err != nil {
+		_, yes := err.(*ProfileNotFound)
+		return yes
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	du "io.twasyl/devcore/pkg/utils"
+)
+
+const defaultAdoptiumAPIURL = "https://api.adoptium.net"
+
+// JDK describes where the JDKs installed by `devcore jdk install` come from.
+type JDK struct {
+	// APIURL is the base URL of the Adoptium API, or of a mirror of it, https://api.adoptium.net by default.
+	APIURL string `json:"api-url,omitempty"`
+}
+
+// JDKRelease is a release of the Eclipse Temurin JDK built for a platform.
+type JDKRelease struct {
+	// Version is the semantic version of the release, e.g. 17.0.8+7.
+	Version string
+	// Name is the name of the release, which is also the directory at the root of its archive, e.g. jdk-17.0.8+7.
+	Name     string
+	URL      string
+	Checksum string
+	// Platform is the platform the release is built for.
+	Platform Platform
+}
+
+type adoptiumBinary struct {
+	Package struct {
+		Name     string `json:"name"`
+		Link     string `json:"link"`
+		Checksum string `json:"checksum"`
+	} `json:"package"`
+}
+
+type adoptiumVersion struct {
+	SemVer string `json:"semver"`
+}
+
+// adoptiumAsset is an element of the response of the /v3/assets/latest endpoint.
+type adoptiumAsset struct {
+	Binary      adoptiumBinary  `json:"binary"`
+	ReleaseName string          `json:"release_name"`
+	Version     adoptiumVersion `json:"version"`
+}
+
+// adoptiumRelease is an element of the response of the /v3/assets/version endpoint.
+type adoptiumRelease struct {
+	Binaries    []adoptiumBinary `json:"binaries"`
+	ReleaseName string           `json:"release_name"`
+	VersionData adoptiumVersion  `json:"version_data"`
+}
+
+func (j *JDK) apiURL() string {
+	if j.APIURL != "" {
+		return strings.TrimSuffix(j.APIURL, "/")
+	}
+	return defaultAdoptiumAPIURL
+}
+
+// JDKTool returns the tool installing the JDKs, under the jdk name. Its versions are the semantic versions of the
+// releases and the java binary of the active one is linked in the bin directory.
+func JDKTool() Tool {
+	return Tool{
+		Name:            "jdk",
+		Description:     "Eclipse Temurin Java Development Kit",
+		CommandLineName: "java",
+		Home:            jdkHome("jdk-{{.Version}}", runtime.GOOS),
+		HomeEnv:         "JAVA_HOME",
+		Binary:          "bin/java",
+		VersionArgs:     []string{"-version"},
+		VersionRegex:    `version "([\d._]+)`,
+	}
+}
+
+// legacyJavaVersion matches the versions reported by Java 8 and older, like 1.8.0_382.
+var legacyJavaVersion = regexp.MustCompile(`^1\.(\d+)\.0_(\d+)$`)
+
+// javaVersion converts a version reported by java -version to the versioning scheme of the releases: 1.8.0_382 is
+// reported by the release 8.0.382.
+func javaVersion(reported string) string {
+	if match := legacyJavaVersion.FindStringSubmatch(reported); match != nil {
+		return match[1] + ".0." + match[2]
+	}
+	return reported
+}
+
+// jdkHome returns the location of the JDK's home in the archive, built for the operating system, whose root directory
+// is given.
+func jdkHome(root string, os string) string {
+	if os == "darwin" {
+		return root + "/Contents/Home"
+	}
+	return root
+}
+
+// FindJDKRelease returns the release of the JDK matching the specifier, built for the platform. The specifier is either
+// a feature version, like 17, resolved to its latest GA release, or an exact version like 17.0.8+7.
+func (j *JDK) FindJDKRelease(specifier string, platform Platform) (JDKRelease, error) {
+	query := url.Values{}
+	query.Set("os", adoptiumOS(platform))
+	query.Set("architecture", adoptiumArch(platform))
+	query.Set("image_type", "jdk")
+	query.Set("jvm_impl", "hotspot")
+	query.Set("vendor", "eclipse")
+
+	if _, err := strconv.Atoi(specifier); err == nil {
+		var assets []adoptiumAsset
+		err := fetchJSON(fmt.Sprintf("%s/v3/assets/latest/%s/hotspot?%s", j.apiURL(), specifier, query.Encode()), &assets)
+		if err != nil {
+			return JDKRelease{}, err
+		}
+		if len(assets) > 0 {
+			return newJDKRelease(assets[0].Version.SemVer, assets[0].ReleaseName, assets[0].Binary, platform), nil
+		}
+	} else {
+		query.Set("release_type", "ga")
+		versionRange := fmt.Sprintf("[%s,%s]", specifier, specifier)
+		var releases []adoptiumRelease
+		err := fetchJSON(fmt.Sprintf("%s/v3/assets/version/%s?%s", j.apiURL(), url.PathEscape(versionRange), query.Encode()), &releases)
+		if err != nil {
+			return JDKRelease{}, err
+		}
+		for _, release := range releases {
+			if len(release.Binaries) > 0 {
+				return newJDKRelease(release.VersionData.SemVer, release.ReleaseName, release.Binaries[0], platform), nil
+			}
+		}
+	}
+	return JDKRelease{}, fmt.Errorf("no JDK %s release found for %s", specifier, platform)
+}
+
+func newJDKRelease(semVer string, name string, binary adoptiumBinary, platform Platform) JDKRelease {
+	return JDKRelease{Version: semVer, Name: name, URL: binary.Package.Link, Checksum: binary.Package.Checksum, Platform: platform}
+}
+
+func fetchJSON(url string, value interface{}) error {
+	content, err := du.FetchContent(url)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, value)
+}
+
+// Tool returns the tool installing this release.
+func (r JDKRelease) Tool() Tool {
+	tool := JDKTool()
+	tool.URL = r.URL
+	tool.Archive = "tar.gz"
+	if strings.HasSuffix(r.URL, ".zip") {
+		tool.Archive = "zip"
+	}
+	tool.Home = jdkHome(r.Name, r.Platform.OS)
+	if r.Checksum != "" {
+		tool.Checksums = map[string]string{path.Base(r.URL): r.Checksum}
+	}
+	return tool
+}
+
+// InstalledJDK returns the highest installed version of the JDK matching the specifier: either a feature version, like
+// 17, or a version constraint.
+func InstalledJDK(specifier string) (string, error) {
+	if _, err := strconv.Atoi(specifier); err == nil {
+		specifier = specifier + ".x"
+	}
+	tool := JDKTool()
+	return tool.installedVersionMatching(specifier)
+}
+
+func adoptiumOS(platform Platform) string {
+	if platform.OS == "darwin" {
+		return "mac"
+	}
+	return platform.OS
+}
+
+func adoptiumArch(platform Platform) string {
+	switch platform.Arch {
+	case "amd64":
+		return "x64"
+	case "arm64":
+		return "aarch64"
+	case "386":
+		return "x86"
+	}
+	return platform.Arch
+}
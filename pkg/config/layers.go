@@ -0,0 +1,315 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// systemConfigFiles are the locations of the system configuration, the first existing one being used. The
+// DEVCORE_SYSTEM_CONFIG environment variable replaces them.
+var systemConfigFiles = []string{"/etc/devcore/config.yaml", "/etc/devcore/config.yml", "/etc/devcore/config.json"}
+
+// projectConfigFiles are the names of the project configuration files, looked for from the working directory up to
+// the projects directory.
+var projectConfigFiles = []string{".devcore.yaml", ".devcore.yml", ".devcore.json"}
+
+// projectSettings are the settings a project configuration may set. A project configuration comes with the sources of
+// the project, so it can only choose the versions of the tools: it can't define the contexts devcore starts, the
+// commands it runs or where it downloads from.
+var projectSettings = []string{"default-tools-version"}
+
+// layer is a configuration file merged into the configuration.
+type layer struct {
+	// Kind is system, team, user or project.
+	Kind string
+	File string
+	// values are the settings of the layer, as generic JSON values
+	values map[string]interface{}
+}
+
+// Origin returns the description of the layer shown by `config view --show-origin`.
+func (l layer) Origin() string {
+	return fmt.Sprintf("%s:%s", l.Kind, l.File)
+}
+
+// layers are the layers of the loaded configuration, from the lowest precedence to the highest.
+var layers []layer
+
+// origins are the origins of the loaded settings, keyed by path. Settings without origin have their default value.
+var origins = map[string]string{}
+
+// loadLayers merges the layers of the configuration into Config, each layer overriding the previous ones:
+//   - the system configuration, in /etc/devcore or DEVCORE_SYSTEM_CONFIG
+//   - the team configurations listed by the team-configs of the system and user configurations, e.g. checked in a
+//     shared repository
+//   - the user configuration
+//   - the project configuration, a .devcore.yaml or .devcore.json file in the working directory or one of its parents
+//     up to the projects directory, only setting the projectSettings
+//
+// The values set by a layer override the values of the previous layers, empty ones included: settings a layer doesn't
+// set are absent from its values. Objects are merged key by key and lists of named elements, like contexts and
+// profiles, element by element. Changes are only ever saved to the user configuration.
+func loadLayers(user DevCoreConfig, userValues map[string]interface{}) error {
+	layers = make([]layer, 0)
+	origins = map[string]string{}
+
+	system, err := findSystemConfigFile()
+	if err != nil {
+		return err
+	}
+	var systemConfig DevCoreConfig
+	if system != "" {
+		if systemConfig, err = addLayer("system", system); err != nil {
+			return err
+		}
+	}
+
+	for _, team := range append(resolveTeamConfigs(system, systemConfig.TeamConfigs), resolveTeamConfigs(configFile(), user.TeamConfigs)...) {
+		if _, err := addLayer("team", team); err != nil {
+			return err
+		}
+	}
+
+	layers = append(layers, layer{Kind: "user", File: configFile(), values: userValues})
+
+	// The projects directory bounding the lookup of the project configuration is set by the previous layers
+	lower, err := mergeLayers()
+	if err != nil {
+		return err
+	}
+	lower.fillDefaultProjectsDir()
+	if project := findProjectConfigFile(lower.ProjectsDir); project != "" {
+		if _, err := addLayer("project", project); err != nil {
+			return err
+		}
+		restrictProjectLayer()
+	}
+
+	Config, err = mergeLayers()
+	return err
+}
+
+// mergeLayers returns the configuration resulting from the layers loaded so far.
+func mergeLayers() (DevCoreConfig, error) {
+	merged := map[string]interface{}{}
+	for _, layer := range layers {
+		// Every layer has been migrated to the current version
+		delete(layer.values, "version")
+		merged = overlay(merged, layer.values, "", layer.Origin()).(map[string]interface{})
+	}
+	merged["version"] = currentVersion
+
+	return Decode(merged)
+}
+
+// addLayer reads the configuration file of a layer.
+func addLayer(kind string, file string) (DevCoreConfig, error) {
+	read, values, _, err := readConfig(file)
+	if err != nil {
+		return read, fmt.Errorf("%s configuration: %w", kind, err)
+	}
+	layers = append(layers, layer{Kind: kind, File: file, values: values})
+	return read, nil
+}
+
+// restrictProjectLayer drops the settings of the project layer, the last one, that are not projectSettings.
+func restrictProjectLayer() {
+	project := layers[len(layers)-1]
+	for key := range project.values {
+		if key != "version" && !contains(projectSettings, key) {
+			fmt.Fprintf(os.Stderr, "Warning: %s can not be set by the project configuration %s, it is ignored\n", key, project.File)
+			delete(project.values, key)
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+func findSystemConfigFile() (string, error) {
+	if file := os.Getenv("DEVCORE_SYSTEM_CONFIG"); file != "" {
+		return filepath.Abs(file)
+	}
+	for _, file := range systemConfigFiles {
+		if _, err := os.Stat(file); err == nil {
+			return file, nil
+		}
+	}
+	return "", nil
+}
+
+// resolveTeamConfigs returns the paths of the team configurations, relative paths being relative to the directory of
+// the configuration listing them.
+func resolveTeamConfigs(file string, teamConfigs []string) []string {
+	resolved := make([]string, 0)
+	for _, team := range teamConfigs {
+		if strings.HasPrefix(team, "~"+string(os.PathSeparator)) {
+			userHomeDir, _ := os.UserHomeDir()
+			team = filepath.Join(userHomeDir, team[2:])
+		} else if !filepath.IsAbs(team) {
+			team = filepath.Join(filepath.Dir(file), team)
+		}
+		resolved = append(resolved, team)
+	}
+	return resolved
+}
+
+// findProjectConfigFile looks for a project configuration in the working directory and its parents, up to the
+// projects directory.
+func findProjectConfigFile(projectsDir string) string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	file, _ := findInParents(dir, projectConfigFiles, projectsDir)
+	return file
+}
+
+// overlay returns the lower values overridden by the upper ones, recording the origin of the upper values. Only
+// missing upper values, not empty ones, leave the lower values.
+func overlay(lower interface{}, upper interface{}, path string, origin string) interface{} {
+	if upper == nil {
+		return lower
+	}
+
+	lowerObject, _ := lower.(map[string]interface{})
+	if upperObject, isObject := upper.(map[string]interface{}); isObject {
+		merged := make(map[string]interface{})
+		for key, value := range lowerObject {
+			merged[key] = value
+		}
+		for key, value := range upperObject {
+			merged[key] = overlay(lowerObject[key], value, join(path, key), origin)
+		}
+		return merged
+	}
+
+	lowerList, _ := lower.([]interface{})
+	if upperList, isList := upper.([]interface{}); isList && areNamed(lowerList) && areNamed(upperList) {
+		merged := append([]interface{}{}, lowerList...)
+		for _, element := range upperList {
+			name := elementName(element)
+			replaced := false
+			for index := range merged {
+				if elementName(merged[index]) == name {
+					merged[index] = overlay(merged[index], element, join(path, name), origin)
+					replaced = true
+				}
+			}
+			if !replaced {
+				merged = append(merged, overlay(nil, element, join(path, name), origin))
+			}
+		}
+		return merged
+	}
+
+	setOrigin(path, upper, origin)
+	return upper
+}
+
+// isEmpty tells if the value is a zero value.
+func isEmpty(value interface{}) bool {
+	switch value := value.(type) {
+	case nil:
+		return true
+	case string:
+		return value == ""
+	case bool:
+		return !value
+	case float64:
+		return value == 0
+	case []interface{}:
+		return len(value) == 0
+	case map[string]interface{}:
+		return len(value) == 0
+	}
+	return false
+}
+
+// setOrigin records the origin of the value at the path and of the values it contains.
+func setOrigin(path string, value interface{}, origin string) {
+	for key := range origins {
+		if strings.HasPrefix(key, path+".") {
+			delete(origins, key)
+		}
+	}
+	for _, leaf := range flatten(value, path) {
+		origins[leaf.Path] = origin
+	}
+}
+
+// Setting is a value of the configuration designated by its path.
+type Setting struct {
+	Path   string      `json:"path"`
+	Value  interface{} `json:"value"`
+	Origin string      `json:"origin"`
+}
+
+// Settings returns the values of the configuration, sorted by path, with the layer, profile or environment variable
+// they come from. The origin of the values not set is default.
+func (c *DevCoreConfig) Settings() ([]Setting, error) {
+	values, err := c.Values()
+	if err != nil {
+		return nil, err
+	}
+
+	settings := flatten(values, "")
+	for index := range settings {
+		settings[index].Origin = "default"
+		for path := settings[index].Path; path != ""; path = parentPath(path) {
+			if origin, found := origins[path]; found {
+				settings[index].Origin = origin
+				break
+			}
+		}
+	}
+	sort.Slice(settings, func(i, j int) bool {
+		return settings[i].Path < settings[j].Path
+	})
+	return settings, nil
+}
+
+// flatten lists the scalar values contained by the value, lists of named elements being designated by the names.
+func flatten(value interface{}, path string) []Setting {
+	settings := make([]Setting, 0)
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			settings = append(settings, flatten(child, join(path, key))...)
+		}
+	case []interface{}:
+		for index, element := range value {
+			key := fmt.Sprint(index)
+			if name := elementName(element); name != "" {
+				key = name
+			}
+			settings = append(settings, flatten(element, join(path, key))...)
+		}
+	default:
+		settings = append(settings, Setting{Path: path, Value: value})
+	}
+	return settings
+}
+
+func join(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func parentPath(path string) string {
+	if index := strings.LastIndex(path, "."); index >= 0 {
+		return path[:index]
+	}
+	return ""
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// layeredTest writes the system, team and user configurations to a temporary directory and points the loading at
+// them. It returns the directory.
+func layeredTest(t *testing.T, team string, user string) string {
+	dir := t.TempDir()
+	files := map[string]string{
+		"system.json": `{"version": 2, "team-configs": ["team.json"]}`,
+		"team.json":   team,
+		"config.json": user,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	previousFile, previousConfig, previousLoaded := loadedFile, Config, loadedValues
+	t.Setenv("DEVCORE_SYSTEM_CONFIG", filepath.Join(dir, "system.json"))
+	loadedFile = filepath.Join(dir, "config.json")
+	t.Cleanup(func() {
+		loadedFile, Config, loadedValues = previousFile, previousConfig, previousLoaded
+		layers, origins = nil, map[string]string{}
+	})
+	return dir
+}
+
+func loadTestLayers(t *testing.T) {
+	user, userValues, err := loadConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loadLayers(user, userValues); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// chdir changes the working directory for the test.
+func chdir(t *testing.T, dir string) {
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(previous)
+	})
+}
+
+func TestEmptyValuesOverrideLowerLayers(t *testing.T) {
+	layeredTest(t,
+		`{"version": 2, "verification": {"require-checksum": true}, "network": {"retries": 3}}`,
+		`{"version": 2, "verification": {"require-checksum": false}, "network": {"retries": 0}}`)
+	loadTestLayers(t)
+
+	if Config.Verification.RequireChecksum {
+		t.Error("Expected the user configuration to disable the checksum requirement of the team configuration")
+	}
+	if Config.Network.Retries == nil || *Config.Network.Retries != 0 {
+		t.Errorf("Expected the user configuration to disable the retries, got %v", Config.Network.Retries)
+	}
+	if origin := origins["network.retries"]; origin != "user:"+configFile() {
+		t.Errorf("Expected network.retries to come from the user configuration, got %s", origin)
+	}
+}
+
+func TestMissingValuesKeepLowerLayers(t *testing.T) {
+	layeredTest(t,
+		`{"version": 2, "verification": {"require-checksum": true}, "network": {"retries": 3}}`,
+		`{"version": 2, "projects-dir": "/src"}`)
+	loadTestLayers(t)
+
+	if !Config.Verification.RequireChecksum {
+		t.Error("Expected the checksum requirement of the team configuration to be kept")
+	}
+	if Config.Network.Retries == nil || *Config.Network.Retries != 3 {
+		t.Errorf("Expected the retries of the team configuration to be kept, got %v", Config.Network.Retries)
+	}
+}
+
+func TestProjectLayerSettings(t *testing.T) {
+	dir := layeredTest(t, `{"version": 2}`, `{"version": 2}`)
+	project := filepath.Join(dir, "projects", "api")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := `{"version": 2, "default-tools-version": {"kubectl": "1.21.0"}, "jenkins": {"cli": "/tmp/cli.sh"},
+		"docker-compose": {"current-context": "app", "contexts": [{"name": "app", "file": "docker-compose.yml"}]}}`
+	if err := os.WriteFile(filepath.Join(project, ".devcore.json"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"version": 2, "projects-dir": "`+filepath.Join(dir, "projects")+`"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, project)
+	loadTestLayers(t)
+
+	if Config.DefaultToolsVersion["kubectl"] != "1.21.0" {
+		t.Errorf("Expected the project configuration to set the default tools version, got %v", Config.DefaultToolsVersion)
+	}
+	if Config.Jenkins.Cli != "" {
+		t.Errorf("Expected the project configuration not to set the Jenkins CLI, got %s", Config.Jenkins.Cli)
+	}
+	if Config.DockerCompose.CurrentContext != "" || len(Config.DockerCompose.Contexts) != 0 {
+		t.Errorf("Expected the project configuration not to set docker compose contexts, got %+v", Config.DockerCompose)
+	}
+}
+
+func TestProjectLayerLookupStopsAtProjectsDir(t *testing.T) {
+	dir := layeredTest(t, `{"version": 2}`, `{"version": 2}`)
+	projects := filepath.Join(dir, "projects")
+	project := filepath.Join(projects, "api")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"version": 2, "projects-dir": "`+projects+`"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	content := `{"version": 2, "default-tools-version": {"kubectl": "1.21.0"}}`
+	if err := os.WriteFile(filepath.Join(dir, ".devcore.json"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, project)
+	loadTestLayers(t)
+
+	for _, layer := range layers {
+		if layer.Kind == "project" {
+			t.Errorf("Expected the project configuration above the projects directory to be ignored, got %s", layer.File)
+		}
+	}
+}
+
+func TestSaveOnlyWritesUserValues(t *testing.T) {
+	layeredTest(t,
+		`{"version": 2, "verification": {"require-checksum": true}, "network": {"retries": 3}}`,
+		`{"version": 2, "projects-dir": "/src"}`)
+	loadTestLayers(t)
+	var err error
+	if loadedValues, err = Config.Values(); err != nil {
+		t.Fatal(err)
+	}
+
+	Config.ServersDir = "/servers"
+	if err := saveConfig(&Config); err != nil {
+		t.Fatal(err)
+	}
+
+	_, saved, _, err := readConfig(configFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{"version": float64(currentVersion), "projects-dir": "/src", "servers-dir": "/servers"}
+	if !reflect.DeepEqual(saved, expected) {
+		t.Errorf("Expected only the values of the user and the changes to be saved, got %v", saved)
+	}
+}
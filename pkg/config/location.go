@@ -0,0 +1,200 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	du "io.twasyl/devcore/pkg/utils"
+)
+
+// ConfigFile is the configuration file to use instead of the one of the devcore directory, set by the --config flag.
+// The DEVCORE_CONFIG environment variable is used when empty.
+var ConfigFile = ""
+
+// homeDir is the devcore directory holding the configuration, the download cache, the plugins and the tool manifests,
+// resolved once.
+var homeDir = ""
+
+// loadedFile is the configuration file loaded by Load.
+var loadedFile = ""
+
+// resolveHomeDir returns the devcore directory: DEVCORE_HOME when set, ~/.devcore when it exists for compatibility,
+// devcore under XDG_CONFIG_HOME when set, ~/.devcore otherwise.
+func resolveHomeDir() (string, error) {
+	if dir := os.Getenv("DEVCORE_HOME"); dir != "" {
+		return filepath.Abs(dir)
+	}
+
+	userHomeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("can not determine user home dir: %w", err)
+	}
+
+	legacy := filepath.Join(userHomeDir, ".devcore")
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		if _, err := os.Stat(legacy); os.IsNotExist(err) {
+			return filepath.Join(xdg, "devcore"), nil
+		}
+	}
+	return legacy, nil
+}
+
+// resolveConfigFile returns the configuration file: the one given by --config or DEVCORE_CONFIG, otherwise config.yaml
+// of the devcore directory when it exists and config.json of the devcore directory by default.
+func resolveConfigFile() (string, error) {
+	file := ConfigFile
+	if file == "" {
+		file = os.Getenv("DEVCORE_CONFIG")
+	}
+	if file != "" {
+		return filepath.Abs(file)
+	}
+
+	for _, name := range []string{"config.yaml", "config.yml"} {
+		file := filepath.Join(configDir(), name)
+		if _, err := os.Stat(file); err == nil {
+			return file, nil
+		}
+	}
+	return filepath.Join(configDir(), "config.json"), nil
+}
+
+func isYAML(file string) bool {
+	extension := filepath.Ext(file)
+	return extension == ".yaml" || extension == ".yml"
+}
+
+// encodeConfig encodes the values of the configuration for the file, in YAML or JSON depending on its extension.
+func encodeConfig(file string, values interface{}) ([]byte, error) {
+	if !isYAML(file) {
+		return json.MarshalIndent(values, "", "  ")
+	}
+	return yaml.Marshal(values)
+}
+
+// override is a setting of the configuration overridden by an environment variable.
+type override struct {
+	// file is the value in the configuration file, nil when absent
+	file interface{}
+	// applied is the value of the environment variable, as set in the configuration
+	applied interface{}
+}
+
+// overrides are the settings overridden by environment variables, keyed by path. They are not saved.
+var overrides = map[string]override{}
+
+// OverrideVariable returns the environment variable overriding the setting of the path, e.g. DEVCORE_PROJECTS_DIR for
+// projects-dir and DEVCORE_JENKINS_CURRENT_CONTEXT for jenkins.current-context.
+func OverrideVariable(path string) string {
+	return "DEVCORE_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(path))
+}
+
+// applyOverrides sets the scalar settings whose DEVCORE_<KEY> environment variable is set.
+func (c *DevCoreConfig) applyOverrides() error {
+	overrides = map[string]override{}
+
+	for _, path := range scalarPaths(reflect.TypeOf(*c), "") {
+		raw, set := os.LookupEnv(OverrideVariable(path))
+		if !set {
+			continue
+		}
+
+		file, _ := c.Get(path)
+		if err := c.Set(path, raw); err != nil {
+			return fmt.Errorf("invalid %s: %w", OverrideVariable(path), err)
+		}
+		applied, _ := c.Get(path)
+		overrides[path] = override{file: file, applied: applied}
+		setOrigin(path, applied, "env:"+OverrideVariable(path))
+	}
+	return nil
+}
+
+// withoutOverrides returns the configuration to save: the settings overridden by environment variables and left as
+// is by the command get their value of the file back.
+func (c *DevCoreConfig) withoutOverrides() (DevCoreConfig, error) {
+	saved := *c
+	for path, override := range overrides {
+		if current, err := c.Get(path); err != nil || current != override.applied {
+			continue
+		}
+		if err := saved.setValue(path, override.file); err != nil {
+			return saved, err
+		}
+	}
+	return saved, nil
+}
+
+// scalarPaths lists the paths of the strings, numbers and booleans of the configuration's structure, lists and maps
+// being skipped.
+func scalarPaths(t reflect.Type, prefix string) []string {
+	paths := make([]string, 0)
+	for index := 0; index < t.NumField(); index++ {
+		field := t.Field(index)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" || name == "version" || name == "current-profile" {
+			continue
+		}
+
+		kind := field.Type.Kind()
+		if kind == reflect.Ptr {
+			kind = field.Type.Elem().Kind()
+		}
+		switch kind {
+		case reflect.Struct:
+			paths = append(paths, scalarPaths(field.Type, prefix+name+".")...)
+		case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+			paths = append(paths, prefix+name)
+		}
+	}
+	return paths
+}
+
+func ensureConfigFileSystemElements() error {
+	if du.DryRun {
+		if _, err := os.Stat(configFile()); errors.Is(err, os.ErrNotExist) {
+			du.Plan("create the configuration file %s", configFile())
+		}
+		return nil
+	}
+
+	for _, dir := range []string{configDir(), filepath.Dir(configFile())} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("can not create configuration directory: %w", err)
+		}
+	}
+
+	if _, err := os.Stat(configFile()); errors.Is(err, os.ErrNotExist) {
+		content := "{}"
+		if isYAML(configFile()) {
+			content = ""
+		}
+		if err := os.WriteFile(configFile(), []byte(content), 0600); err != nil {
+			return fmt.Errorf("can not create configuration file: %w", err)
+		}
+	}
+	return nil
+}
+
+// configDir returns the devcore directory.
+func configDir() string {
+	if homeDir == "" {
+		homeDir, _ = resolveHomeDir()
+	}
+	return homeDir
+}
+
+// configFile returns the configuration file in use.
+func configFile() string {
+	if loadedFile != "" {
+		return loadedFile
+	}
+	file, _ := resolveConfigFile()
+	return file
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	du "io.twasyl/devcore/pkg/utils"
+)
+
+// Network describes how devcore reaches the network, typically from inside a corporate network.
+type Network struct {
+	// Mirrors lists the upstream sites to download from mirrors instead.
+	Mirrors []Mirror `json:"mirrors"`
+	// Proxy is the URL of the HTTP proxy to use. The HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are used
+	// when empty.
+	Proxy string `json:"proxy"`
+	// CABundle is a PEM file holding certificate authorities to trust in addition to the system ones.
+	CABundle string `json:"ca-bundle"`
+	// Timeout is how long to wait for a connection, a response or the next bytes of a download, e.g. 1m. Defaults to
+	// 30s.
+	Timeout string `json:"timeout,omitempty"`
+	// Retries is the number of times a download failing with a transient error is attempted again. Defaults to 3.
+	Retries *int `json:"retries,omitempty"`
+}
+
+// Mirror makes the URLs starting with Upstream be downloaded from the same path under Mirror, e.g. https://get.helm.sh
+// from https://artifacts.example.com/helm.
+type Mirror struct {
+	Upstream string `json:"upstream"`
+	Mirror   string `json:"mirror"`
+}
+
+// apply configures the downloads made by devcore accordingly to the network settings. The tokens are sent to the
+// hosts they are keyed by.
+func (n *Network) apply(tokens map[string]string) error {
+	rules := make([]du.RewriteRule, 0)
+	for _, mirror := range n.Mirrors {
+		rules = append(rules, du.RewriteRule{Prefix: mirror.Upstream, Replacement: mirror.Mirror})
+	}
+	du.SetRewriteRules(rules)
+
+	settings := du.DefaultHTTPSettings
+	settings.Proxy = n.Proxy
+	settings.CABundle = n.CABundle
+	settings.Tokens = tokens
+	if n.Timeout != "" {
+		timeout, err := time.ParseDuration(n.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid network timeout %s: %w", n.Timeout, err)
+		}
+		settings.Timeout = timeout
+	}
+	if n.Retries != nil {
+		settings.Retries = *n.Retries
+	}
+	return du.ConfigureHTTP(settings)
+}
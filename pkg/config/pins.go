@@ -0,0 +1,140 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"io.twasyl/devcore/pkg/version"
+)
+
+// ToolVersionsFile is the name of the file, at the root of a project, pinning the versions of the tools the project
+// needs. Each line contains the name of a tool followed by its version, lines starting with # are ignored.
+const ToolVersionsFile = ".devcore-tools"
+
+// PinnedTool is a tool version pinned in a ToolVersionsFile.
+type PinnedTool struct {
+	Name    string
+	Version string
+}
+
+// FindToolVersionsFile looks for a ToolVersionsFile in the given directory and its parents. The lookup stops at the
+// projects directory when the directory is inside it, at the root of the file system otherwise. An empty string is
+// returned when no file is found.
+func FindToolVersionsFile(dir string) (string, error) {
+	return findInParents(dir, []string{ToolVersionsFile}, Config.ProjectsDir)
+}
+
+// findInParents looks for a file with one of the names in the directory and its parents. The lookup stops at the
+// projects directory when the directory is inside it, at the root otherwise.
+func findInParents(dir string, names []string, projectsDir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	projectsDir, err = filepath.Abs(projectsDir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		for _, name := range names {
+			file := filepath.Join(dir, name)
+			if info, err := os.Stat(file); err == nil && !info.IsDir() {
+				return file, nil
+			} else if err != nil && !os.IsNotExist(err) {
+				return "", err
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if dir == projectsDir || parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// InstalledPinnedVersion returns the installed version of the tool satisfying a pinned version: the version itself
+// when it is exact, the newest installed version matching it when it is a constraint like 1.24.x or ~1.24. The JDK
+// can also be pinned by feature version, like 17, as done by jdk use.
+func (t *Tool) InstalledPinnedVersion(pinned string) (string, error) {
+	if t.Name == JDKTool().Name {
+		return InstalledJDK(pinned)
+	}
+
+	if version.IsExact(pinned) {
+		if !t.IsInstalled(pinned, CurrentPlatform()) {
+			return "", &ToolVersionNotInstalled{Name: t.Name, Version: pinned}
+		}
+		return pinned, nil
+	}
+	return t.installedVersionMatching(pinned)
+}
+
+// installedVersionMatching returns the newest installed version of the tool matching the version constraint.
+func (t *Tool) installedVersionMatching(specifier string) (string, error) {
+	versions, err := t.InstalledVersions()
+	if err != nil {
+		return "", err
+	}
+
+	constraint, err := version.ParseConstraint(specifier)
+	if err != nil {
+		return "", err
+	}
+
+	if installed := constraint.Highest(versions); installed != "" {
+		return installed, nil
+	}
+	return "", &ToolVersionNotInstalled{Name: t.Name, Version: specifier}
+}
+
+// ReadToolVersions reads the tools pinned in a ToolVersionsFile.
+func ReadToolVersions(file string) ([]PinnedTool, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pins := make([]PinnedTool, 0)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expecting '<tool> <version>', got '%s'", file, line, text)
+		}
+		pins = append(pins, PinnedTool{Name: fields[0], Version: fields[1]})
+	}
+	return pins, scanner.Err()
+}
+
+// PinnedVersion returns the version of the tool pinned for the given directory, and the file pinning it. Empty strings
+// are returned when the tool isn't pinned.
+func PinnedVersion(dir string, name string) (string, string, error) {
+	file, err := FindToolVersionsFile(dir)
+	if err != nil || file == "" {
+		return "", "", err
+	}
+
+	pins, err := ReadToolVersions(file)
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, pin := range pins {
+		if pin.Name == name {
+			return pin.Version, file, nil
+		}
+	}
+	return "", "", nil
+}
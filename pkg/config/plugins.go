@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// PluginPrefix is the prefix of the name of the executables extending devcore. devcore-<name> is run by
+// `devcore <name>`.
+const PluginPrefix = "devcore-"
+
+// Plugin is an executable extending devcore with a command.
+type Plugin struct {
+	// Name is the name of the command running the plugin.
+	Name string `json:"name"`
+	// Path is the location of the executable.
+	Path string `json:"path"`
+}
+
+// PluginsDir returns the directory in which plugins are looked up before the PATH.
+func PluginsDir() string {
+	return filepath.Join(configDir(), "plugins")
+}
+
+// FindPlugins lists the plugins found in the plugins directory and in the directories of the PATH, in lookup order.
+// Several plugins can have the same name, only the first one found is run.
+func FindPlugins() []Plugin {
+	plugins := make([]Plugin, 0)
+	seen := make(map[string]bool)
+
+	dirs := append([]string{PluginsDir()}, filepath.SplitList(os.Getenv("PATH"))...)
+	for _, dir := range dirs {
+		if dir == "" || seen[dir] {
+			continue
+		}
+		seen[dir] = true
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if plugin, ok := pluginOf(dir, entry); ok {
+				plugins = append(plugins, plugin)
+			}
+		}
+	}
+	return plugins
+}
+
+// pluginOf returns the plugin a directory entry is, when it is an executable named like one.
+func pluginOf(dir string, entry os.DirEntry) (Plugin, bool) {
+	name := entry.Name()
+	if !strings.HasPrefix(name, PluginPrefix) {
+		return Plugin{}, false
+	}
+	if runtime.GOOS == "windows" {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	path := filepath.Join(dir, entry.Name())
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || (runtime.GOOS != "windows" && info.Mode()&0111 == 0) {
+		return Plugin{}, false
+	}
+
+	name = strings.TrimPrefix(name, PluginPrefix)
+	if name == "" {
+		return Plugin{}, false
+	}
+	return Plugin{Name: name, Path: path}, true
+}
+
+// PluginEnvironment returns the variables added to the environment of plugins: where the configuration is, the names
+// of the current contexts and the active profile.
+func PluginEnvironment() []string {
+	return []string{
+		"DEVCORE_HOME=" + configDir(),
+		"DEVCORE_CONFIG=" + configFile(),
+		"DEVCORE_BIN_DIR=" + Config.BinDir(),
+		"DEVCORE_COMPOSE_CONTEXT=" + Config.DockerCompose.CurrentContext,
+		"DEVCORE_JENKINS_CONTEXT=" + Config.Jenkins.CurrentContext,
+		"DEVCORE_PROFILE=" + ActiveProfile(),
+	}
+}
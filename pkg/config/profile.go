@@ -0,0 +1,217 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ProfileName is the profile to use instead of the current one, set by the --profile flag. The DEVCORE_PROFILE
+// environment variable is used when empty.
+var ProfileName = ""
+
+// Profile overlays the base configuration with the settings of a team or a customer. The docker compose and Jenkins
+// contexts belong to the profile: while it is active, the contexts of the base configuration are not visible.
+type Profile struct {
+	Name                string            `json:"name"`
+	Description         string            `json:"description"`
+	ProjectsDir         string            `json:"projects-dir,omitempty"`
+	ServersDir          string            `json:"servers-dir,omitempty"`
+	DefaultToolsVersion map[string]string `json:"default-tools-version,omitempty"`
+	DockerCompose       DockerCompose     `json:"docker-compose"`
+	Jenkins             Jenkins           `json:"jenkins"`
+}
+
+// baseConfig is the configuration as loaded, without the active profile.
+var baseConfig DevCoreConfig
+
+// profiledConfig is the configuration as loaded, overlaid with the active profile.
+var profiledConfig DevCoreConfig
+
+// profileMissing tells the profile asked for doesn't exist, the base configuration being used instead.
+var profileMissing bool
+
+// FindProfileByName looks in the config for a Profile named with the desired one.
+func (c *DevCoreConfig) FindProfileByName(name string) (Profile, error) {
+	for _, profile := range c.Profiles {
+		if profile.Name == name {
+			return profile, nil
+		}
+	}
+	return Profile{}, &ProfileNotFound{name}
+}
+
+// AddProfile will add the given profile to the configuration.
+func (c *DevCoreConfig) AddProfile(profile Profile) {
+	c.Profiles = append(c.Profiles, profile)
+}
+
+// DeleteProfile will remove the given profile from the configuration.
+func (c *DevCoreConfig) DeleteProfile(toDelete Profile) error {
+	for index, profile := range c.Profiles {
+		if profile.Name == toDelete.Name {
+			profiles := c.Profiles
+			c.Profiles = append(profiles[:index], profiles[index+1:]...)
+			if c.CurrentProfile == toDelete.Name {
+				c.CurrentProfile = ""
+			}
+			return nil
+		}
+	}
+	return &ProfileNotFound{toDelete.Name}
+}
+
+// ActiveProfile returns the name of the profile in use: the one given by --profile or DEVCORE_PROFILE, the current
+// profile otherwise. It is empty when no profile is used, or when the profile asked for doesn't exist.
+func ActiveProfile() string {
+	if profileMissing {
+		return ""
+	} else if ProfileName != "" {
+		return ProfileName
+	} else if name := os.Getenv("DEVCORE_PROFILE"); name != "" {
+		return name
+	}
+	return baseConfig.CurrentProfile
+}
+
+// activateProfile overlays the configuration with the active profile. A profile which doesn't exist, because of a typo
+// or because it has been deleted, is reported and the base configuration is used: the commands fixing the
+// configuration, like profile create, must keep working.
+func (c *DevCoreConfig) activateProfile() {
+	baseConfig = c.clone()
+	profileMissing = false
+
+	if name := ActiveProfile(); name != "" {
+		if profile, err := c.FindProfileByName(name); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s, the base configuration is used\n", err)
+			profileMissing = true
+		} else {
+			*c = c.withProfile(profile)
+			profile.setOrigins()
+		}
+	}
+
+	profiledConfig = c.clone()
+}
+
+// withProfile returns the configuration overlaid with the profile.
+func (c *DevCoreConfig) withProfile(profile Profile) DevCoreConfig {
+	profiled := c.clone()
+	profile = profile.clone()
+
+	if profile.ProjectsDir != "" {
+		profiled.ProjectsDir = profile.ProjectsDir
+	}
+	if profile.ServersDir != "" {
+		profiled.ServersDir = profile.ServersDir
+	}
+	if profiled.DefaultToolsVersion == nil {
+		profiled.DefaultToolsVersion = make(map[string]string)
+	}
+	for name, version := range profile.DefaultToolsVersion {
+		profiled.DefaultToolsVersion[name] = version
+	}
+
+	profiled.DockerCompose = profile.DockerCompose
+	profiled.Jenkins.CurrentContext = profile.Jenkins.CurrentContext
+	profiled.Jenkins.Contexts = profile.Jenkins.Contexts
+	if profile.Jenkins.Cli != "" {
+		profiled.Jenkins.Cli = profile.Jenkins.Cli
+	}
+	return profiled
+}
+
+// setOrigins records the settings coming from the profile for `config view --show-origin`.
+func (p *Profile) setOrigins() {
+	origin := "profile:" + p.Name
+	values := map[string]interface{}{}
+	content, _ := json.Marshal(p)
+	json.Unmarshal(content, &values)
+
+	if p.ProjectsDir != "" {
+		setOrigin("projects-dir", values["projects-dir"], origin)
+	}
+	if p.ServersDir != "" {
+		setOrigin("servers-dir", values["servers-dir"], origin)
+	}
+	for name, version := range p.DefaultToolsVersion {
+		setOrigin("default-tools-version."+name, version, origin)
+	}
+	setOrigin("docker-compose", values["docker-compose"], origin)
+	jenkins, _ := values["jenkins"].(map[string]interface{})
+	setOrigin("jenkins.current-context", jenkins["current-context"], origin)
+	setOrigin("jenkins.contexts", jenkins["contexts"], origin)
+	if p.Jenkins.Cli != "" {
+		setOrigin("jenkins.cli", p.Jenkins.Cli, origin)
+	}
+}
+
+// withoutProfile returns the base configuration to save: the changes made to the settings of the active profile are
+// moved to it, the settings of the base configuration get their value back. When the active profile has just been
+// deleted, the changes made to its settings are dropped.
+func (c *DevCoreConfig) withoutProfile() DevCoreConfig {
+	base := c.clone()
+	name := ActiveProfile()
+	if name == "" {
+		return base
+	}
+
+	loaded, _ := baseConfig.FindProfileByName(name)
+	profile := &Profile{}
+	for index := range base.Profiles {
+		if base.Profiles[index].Name == name {
+			profile = &base.Profiles[index]
+		}
+	}
+
+	if c.ProjectsDir != profiledConfig.ProjectsDir {
+		profile.ProjectsDir = c.ProjectsDir
+	}
+	base.ProjectsDir = baseConfig.ProjectsDir
+	if c.ServersDir != profiledConfig.ServersDir {
+		profile.ServersDir = c.ServersDir
+	}
+	base.ServersDir = baseConfig.ServersDir
+
+	base.DefaultToolsVersion = baseConfig.clone().DefaultToolsVersion
+	for name, version := range c.DefaultToolsVersion {
+		if profiledConfig.DefaultToolsVersion[name] != version {
+			if profile.DefaultToolsVersion == nil {
+				profile.DefaultToolsVersion = make(map[string]string)
+			}
+			profile.DefaultToolsVersion[name] = version
+		}
+	}
+
+	profile.DockerCompose = c.DockerCompose
+	base.DockerCompose = baseConfig.clone().DockerCompose
+	profile.Jenkins.CurrentContext = c.Jenkins.CurrentContext
+	profile.Jenkins.Contexts = c.Jenkins.Contexts
+	base.Jenkins.CurrentContext = baseConfig.Jenkins.CurrentContext
+	base.Jenkins.Contexts = baseConfig.clone().Jenkins.Contexts
+	// The Jenkins CLI belongs to the profile only when the profile sets it
+	if loaded.Jenkins.Cli != "" {
+		if c.Jenkins.Cli != profiledConfig.Jenkins.Cli {
+			profile.Jenkins.Cli = c.Jenkins.Cli
+		}
+		base.Jenkins.Cli = baseConfig.Jenkins.Cli
+	}
+
+	return base
+}
+
+// clone returns a deep copy of the configuration.
+func (c *DevCoreConfig) clone() DevCoreConfig {
+	content, _ := json.Marshal(c)
+	cloned := DevCoreConfig{}
+	json.Unmarshal(content, &cloned)
+	return cloned
+}
+
+// clone returns a deep copy of the profile.
+func (p *Profile) clone() Profile {
+	content, _ := json.Marshal(p)
+	cloned := Profile{}
+	json.Unmarshal(content, &cloned)
+	return cloned
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+// activate loads the configuration with the profile given by --profile.
+func activate(t *testing.T, c DevCoreConfig, profile string) DevCoreConfig {
+	previous := ProfileName
+	ProfileName = profile
+	t.Setenv("DEVCORE_PROFILE", "")
+	t.Cleanup(func() {
+		ProfileName = previous
+		profileMissing = false
+	})
+
+	c.activateProfile()
+	return c
+}
+
+func profiledTestConfig() DevCoreConfig {
+	return DevCoreConfig{
+		ProjectsDir:         "/src",
+		DefaultToolsVersion: map[string]string{"kubectl": "1.23.0", "helm": "3.8.0"},
+		DockerCompose: DockerCompose{
+			CurrentContext: "local",
+			Contexts:       []DockerComposeContext{{Name: "local", File: "/src/docker-compose.yml"}},
+		},
+		Jenkins: Jenkins{Cli: "/opt/jenkins-cli.jar"},
+		Profiles: []Profile{{
+			Name:                "acme",
+			ProjectsDir:         "/acme",
+			DefaultToolsVersion: map[string]string{"kubectl": "1.21.0"},
+			DockerCompose: DockerCompose{
+				Contexts: []DockerComposeContext{{Name: "acme", File: "/acme/docker-compose.yml"}},
+			},
+		}},
+	}
+}
+
+func TestWithoutProfile(t *testing.T) {
+	loaded := profiledTestConfig()
+	c := activate(t, loaded, "acme")
+	if c.ProjectsDir != "/acme" || c.DefaultToolsVersion["kubectl"] != "1.21.0" || c.DefaultToolsVersion["helm"] != "3.8.0" {
+		t.Fatalf("Expected the profile to overlay the configuration, got %+v", c)
+	}
+
+	c.ProjectsDir = "/acme/src"
+	c.DefaultToolsVersion["helm"] = "3.9.0"
+	c.DockerCompose.Contexts = append(c.DockerCompose.Contexts, DockerComposeContext{Name: "db", File: "/acme/db.yml"})
+	c.DockerCompose.CurrentContext = "db"
+	c.Jenkins.Cli = "/acme/jenkins-cli.jar"
+	base := c.withoutProfile()
+
+	if base.ProjectsDir != "/src" || base.Profiles[0].ProjectsDir != "/acme/src" {
+		t.Errorf("Expected the projects directory to be changed in the profile only, got %s and %s", base.ProjectsDir, base.Profiles[0].ProjectsDir)
+	}
+	if !reflect.DeepEqual(base.DefaultToolsVersion, loaded.DefaultToolsVersion) {
+		t.Errorf("Expected the base tools versions to be kept, got %v", base.DefaultToolsVersion)
+	}
+	if expected := map[string]string{"kubectl": "1.21.0", "helm": "3.9.0"}; !reflect.DeepEqual(base.Profiles[0].DefaultToolsVersion, expected) {
+		t.Errorf("Expected the profile's tools versions to be %v, got %v", expected, base.Profiles[0].DefaultToolsVersion)
+	}
+	if !reflect.DeepEqual(base.DockerCompose, loaded.DockerCompose) {
+		t.Errorf("Expected the base docker compose contexts to be kept, got %+v", base.DockerCompose)
+	}
+	if contexts := base.Profiles[0].DockerCompose; contexts.CurrentContext != "db" || len(contexts.Contexts) != 2 {
+		t.Errorf("Expected the context to be added to the profile, got %+v", contexts)
+	}
+	if base.Jenkins.Cli != "/acme/jenkins-cli.jar" || base.Profiles[0].Jenkins.Cli != "" {
+		t.Errorf("Expected the Jenkins CLI, not set by the profile, to be changed in the base configuration, got %s", base.Jenkins.Cli)
+	}
+}
+
+func TestWithoutProfileSettingJenkinsCli(t *testing.T) {
+	loaded := profiledTestConfig()
+	loaded.Profiles[0].Jenkins.Cli = "/acme/jenkins-cli.jar"
+	c := activate(t, loaded, "acme")
+
+	base := c.withoutProfile()
+	if base.Jenkins.Cli != "/opt/jenkins-cli.jar" || base.Profiles[0].Jenkins.Cli != "/acme/jenkins-cli.jar" {
+		t.Errorf("Expected the Jenkins CLI set by the profile to be kept in the profile, got %s and %s", base.Jenkins.Cli, base.Profiles[0].Jenkins.Cli)
+	}
+
+	c.Jenkins.Cli = "/acme/v2/jenkins-cli.jar"
+	base = c.withoutProfile()
+	if base.Jenkins.Cli != "/opt/jenkins-cli.jar" || base.Profiles[0].Jenkins.Cli != "/acme/v2/jenkins-cli.jar" {
+		t.Errorf("Expected the Jenkins CLI set by the profile to be changed in the profile, got %s and %s", base.Jenkins.Cli, base.Profiles[0].Jenkins.Cli)
+	}
+}
+
+func TestWithoutDeletedProfile(t *testing.T) {
+	loaded := profiledTestConfig()
+	c := activate(t, loaded, "acme")
+	if err := c.DeleteProfile(Profile{Name: "acme"}); err != nil {
+		t.Fatal(err)
+	}
+	c.ProjectsDir = "/acme/src"
+
+	base := c.withoutProfile()
+	loaded.Profiles = []Profile{}
+	if !reflect.DeepEqual(base.clone(), loaded.clone()) {
+		t.Errorf("Expected the base configuration to be saved without the deleted profile, got %+v", base)
+	}
+}
+
+func TestMissingProfile(t *testing.T) {
+	loaded := profiledTestConfig()
+	c := activate(t, loaded, "acne")
+
+	if !reflect.DeepEqual(c.clone(), loaded.clone()) || ActiveProfile() != "" {
+		t.Errorf("Expected the base configuration to be used, got %+v with profile '%s'", c, ActiveProfile())
+	}
+
+	c.ProjectsDir = "/work"
+	if base := c.withoutProfile(); base.ProjectsDir != "/work" {
+		t.Errorf("Expected the changes to be saved in the base configuration, got %s", base.ProjectsDir)
+	}
+}
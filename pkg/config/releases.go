@@ -0,0 +1,149 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	du "io.twasyl/devcore/pkg/utils"
+	"io.twasyl/devcore/pkg/version"
+)
+
+const defaultGitHubAPIURL = "https://api.github.com"
+
+// githubReleasesPages is the maximum number of pages of GitHub releases fetched to resolve a version.
+const githubReleasesPages = 5
+
+// ReleaseSource describes where the released versions of a tool are published.
+type ReleaseSource struct {
+	// GitHub is the owner/name of the GitHub repository publishing the releases of the tool.
+	GitHub string `json:"github,omitempty" yaml:"github,omitempty"`
+	// TagPrefix is removed from the release tags to get the versions. Defaults to v.
+	TagPrefix *string `json:"tag-prefix,omitempty" yaml:"tag-prefix,omitempty"`
+	// LatestURL returns the latest version of the tool as plain text. When set, it is used to resolve latest.
+	LatestURL string `json:"latest-url,omitempty" yaml:"latest-url,omitempty"`
+}
+
+// Releases describes the release feeds devcore queries.
+type Releases struct {
+	// GitHubAPIURL is the base URL of the GitHub API, https://api.github.com by default.
+	GitHubAPIURL string `json:"github-api-url"`
+	// GitHubToken is sent to GitHub to avoid the rate limits applied to anonymous requests. The GITHUB_TOKEN environment
+	// variable is used when empty.
+	GitHubToken string `json:"github-token,omitempty"`
+}
+
+type githubRelease struct {
+	TagName    string `json:"tag_name"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+// githubAPIURL returns the base URL of the GitHub API to use.
+func (r *Releases) githubAPIURL() string {
+	if r.GitHubAPIURL != "" {
+		return strings.TrimSuffix(r.GitHubAPIURL, "/")
+	}
+	return defaultGitHubAPIURL
+}
+
+// tokens returns the GitHub token keyed by the hosts it is sent to: GitHub itself and its API.
+func (r *Releases) tokens() map[string]string {
+	token := r.GitHubToken
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	if token == "" {
+		return nil
+	}
+
+	tokens := map[string]string{"github.com": token, "api.github.com": token}
+	if apiURL, err := url.Parse(r.githubAPIURL()); err == nil {
+		tokens[apiURL.Hostname()] = token
+	}
+	return tokens
+}
+
+func (s *ReleaseSource) tagPrefix() string {
+	if s.TagPrefix == nil {
+		return "v"
+	}
+	return *s.TagPrefix
+}
+
+// HasReleaseSource indicates if the releases of the tool can be queried to resolve version specifiers.
+func (t *Tool) HasReleaseSource() bool {
+	return t.Releases.GitHub != "" || t.Releases.LatestURL != ""
+}
+
+// ResolveVersion returns the version of the tool matching the specifier, which is either an exact version or a
+// constraint like latest, 1.25.x or ~3.9 resolved against the release source of the tool.
+func (t *Tool) ResolveVersion(specifier string) (string, error) {
+	if version.IsExact(specifier) {
+		return specifier, nil
+	}
+
+	constraint, err := version.ParseConstraint(specifier)
+	if err != nil {
+		return "", err
+	}
+
+	if !t.HasReleaseSource() {
+		return "", fmt.Errorf("no release source known for %s, an exact version is required", t.Name)
+	}
+
+	if specifier == version.Latest && t.Releases.LatestURL != "" {
+		content, err := du.FetchContent(t.Releases.LatestURL)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimPrefix(strings.TrimSpace(string(content)), t.Releases.tagPrefix()), nil
+	}
+
+	if t.Releases.GitHub == "" {
+		return "", fmt.Errorf("%s can only be resolved to an exact version or latest", t.Name)
+	}
+
+	versions, err := t.githubVersions()
+	if err != nil {
+		return "", err
+	}
+
+	resolved := constraint.Highest(versions)
+	if resolved == "" {
+		return "", fmt.Errorf("no release of %s matches %s", t.Name, specifier)
+	}
+	return resolved, nil
+}
+
+// githubVersions lists the versions of the published, stable, GitHub releases of the tool.
+func (t *Tool) githubVersions() ([]string, error) {
+	versions := make([]string, 0)
+	prefix := t.Releases.tagPrefix()
+
+	for page := 1; page <= githubReleasesPages; page++ {
+		url := fmt.Sprintf("%s/repos/%s/releases?per_page=100&page=%d", Config.Releases.githubAPIURL(), t.Releases.GitHub, page)
+		content, err := du.FetchContent(url)
+		if err != nil {
+			return nil, err
+		}
+
+		releases := make([]githubRelease, 0)
+		if err := json.Unmarshal(content, &releases); err != nil {
+			return nil, fmt.Errorf("can not read the releases of %s: %w", t.Releases.GitHub, err)
+		}
+
+		for _, release := range releases {
+			if !release.Draft && !release.Prerelease && strings.HasPrefix(release.TagName, prefix) {
+				versions = append(versions, strings.TrimPrefix(release.TagName, prefix))
+			}
+		}
+
+		if len(releases) < 100 {
+			break
+		}
+	}
+	return versions, nil
+}
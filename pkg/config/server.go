@@ -7,15 +7,17 @@ import (
 	"path/filepath"
 	"strings"
 
+	"io.twasyl/devcore/pkg/archive"
 	du "io.twasyl/devcore/pkg/utils"
 )
 
 // Server represents an application server that can be installed and used.
 type Server struct {
-	Name           string
-	DefaultVersion string
-	OS             func() string
-	Install        func(version string) error
+	Name           string        `json:"name"`
+	DefaultVersion string        `json:"default-version"`
+	OS             func() string `json:"-"`
+	// Install installs the given version of the server, running the external commands with the runner.
+	Install func(runner du.Runner, version string) error `json:"-"`
 }
 
 // DefaultSupportedServers represents the servers that can be installed using `devcore servers install` with their default
@@ -27,45 +29,108 @@ func init() {
 		{
 			Name:           "tomcat",
 			DefaultVersion: "10.0.10",
-			Install: func(version string) error {
+			Install: func(runner du.Runner, version string) error {
 				tomcatsDir := filepath.Join(Config.ServersDir, "tomcat")
 				versionDir := filepath.Join(tomcatsDir, version)
 
-				if _, err := os.Stat(versionDir); os.IsNotExist(err) == false {
-					return errors.New(fmt.Sprintf("Tomcat %s already present at %s", version, versionDir))
-				}
-
-				if _, err := os.Stat(tomcatsDir); os.IsNotExist(err) {
+				if _, err := os.Stat(tomcatsDir); os.IsNotExist(err) && !du.DryRun {
 					err = os.MkdirAll(tomcatsDir, 0755)
 					if err != nil {
 						return nil
 					}
 				}
 
-				var archive = filepath.Join(os.TempDir(), "tomcat.zip")
+				lock, err := du.LockFile(filepath.Join(tomcatsDir, ".lock"), func() {
+					fmt.Println("Waiting for another devcore process installing Tomcat")
+				})
+				if err != nil {
+					return err
+				}
+				defer lock.Unlock()
+
+				if _, err := os.Stat(versionDir); os.IsNotExist(err) == false {
+					return errors.New(fmt.Sprintf("Tomcat %s already present at %s", version, versionDir))
+				}
+
 				majorVersion := version[0:strings.Index(version, ".")]
-				err := du.DownloadFile(fmt.Sprintf("https://archive.apache.org/dist/tomcat/tomcat-%s/v%s/bin/apache-tomcat-%s.zip", majorVersion, version, version), archive)
+				url := fmt.Sprintf("https://archive.apache.org/dist/tomcat/tomcat-%s/v%s/bin/apache-tomcat-%s.zip", majorVersion, version, version)
+				verification := artifactVerification{URL: url, ChecksumURL: url + ".sha512", SignatureURL: url + ".asc"}
+
+				if du.DryRun {
+					zipFile := filepath.Join(tomcatsDir, ".install-*", "tomcat.zip")
+					if err := verification.download(zipFile); err != nil {
+						return err
+					}
+					du.Plan("verify the checksum of %s", zipFile)
+					du.Plan("extract %s and move its apache-tomcat-%s to %s", zipFile, version, versionDir)
+					return nil
+				}
+
+				tx := beginTransaction()
+				defer tx.rollback()
+
+				var stagingDir string
+				err = tx.apply(func() (err error) {
+					stagingDir, err = os.MkdirTemp(tomcatsDir, ".install-")
+					return err
+				}, func() {
+					os.RemoveAll(stagingDir)
+				})
+				if err != nil {
+					return err
+				}
+				defer os.RemoveAll(stagingDir)
+
+				var zipFile = filepath.Join(stagingDir, "tomcat.zip")
+				err = verification.download(zipFile)
+				if err != nil {
+					return err
+				}
+
+				err = verification.verify(runner, zipFile)
 				if err != nil {
 					return err
 				}
 
-				err = du.Expand(archive, tomcatsDir)
+				err = archive.Extract(zipFile, stagingDir, archive.Options{})
 				if err != nil {
 					return err
 				}
 
-				err = os.Rename(filepath.Join(tomcatsDir, fmt.Sprintf("apache-tomcat-%s", version)), versionDir)
+				err = tx.apply(func() error {
+					return os.Rename(filepath.Join(stagingDir, fmt.Sprintf("apache-tomcat-%s", version)), versionDir)
+				}, func() {
+					os.RemoveAll(versionDir)
+				})
 				if err != nil {
 					return err
 				}
 
-				err = os.Remove(archive)
-				return err
+				tx.commit()
+				return nil
 			},
 		},
 	}
 }
 
+// InstalledVersions lists the installed versions of the server.
+func (s *Server) InstalledVersions() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(Config.ServersDir, s.Name))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	versions := make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			versions = append(versions, entry.Name())
+		}
+	}
+	return versions, nil
+}
+
 func FindServer(name string) (Server, error) {
 	for _, server := range DefaultSupportedServers {
 		if server.Name == name {
@@ -0,0 +1,399 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	du "io.twasyl/devcore/pkg/utils"
+)
+
+// migration upgrades the configuration from the previous version to the next one, working on its raw values.
+type migration struct {
+	description string
+	migrate     func(values map[string]interface{}) error
+}
+
+// migrations upgrade the configuration files written by older versions of devcore, in order: the migration at index i
+// upgrades version i to version i+1. New migrations are appended, the existing ones must never change.
+var migrations = []migration{
+	{
+		description: "record the version of the configuration",
+		migrate: func(values map[string]interface{}) error {
+			return nil
+		},
+	},
+	{
+		// Older versions wrote every setting, the empty values of the settings not set included. Empty values didn't
+		// override the lower layers then, while any value present in a file does now.
+		description: "remove the empty values of the settings not set",
+		migrate: func(values map[string]interface{}) error {
+			removeEmptyValues(values)
+			return nil
+		},
+	},
+}
+
+// currentVersion is the version of the configuration written by this version of devcore.
+var currentVersion = len(migrations)
+
+// loadedValues are the values of the configuration as loaded, against which the changes made by the command are
+// computed when saving.
+var loadedValues map[string]interface{}
+
+// unchangedValues are the values of the configuration, including its layers, profile and overrides, as loaded or as
+// last saved. Saving a configuration having these values writes nothing.
+var unchangedValues map[string]interface{}
+
+// readConfig reads the configuration file, migrating it to the current version. The values set in the file are
+// returned as well, as generic JSON values: unlike the values of the configuration, they don't contain the settings
+// the file doesn't set. The returned version is the one of the file, or the current one when the migrations changed
+// nothing but the version: such a file doesn't need to be backed up and rewritten, the version is recorded the next
+// time the file is saved.
+func readConfig(file string) (DevCoreConfig, map[string]interface{}, int, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return DevCoreConfig{}, nil, 0, err
+	}
+
+	values := make(map[string]interface{})
+	if isYAML(file) {
+		err = yaml.Unmarshal(content, &values)
+	} else {
+		err = json.Unmarshal(content, &values)
+	}
+	if err != nil {
+		return DevCoreConfig{}, nil, 0, fmt.Errorf("can not read configuration file %s: %w", file, err)
+	}
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+
+	version := 0
+	if number, ok := values["version"].(float64); ok {
+		version = int(number)
+	} else if number, ok := values["version"].(int); ok {
+		version = number
+	}
+	if version > currentVersion {
+		return DevCoreConfig{}, nil, version, fmt.Errorf("configuration file %s has version %d, this devcore only knows up to version %d, please upgrade devcore", file, version, currentVersion)
+	}
+
+	// The values are compared without the version, to tell if the migrations changed anything else
+	delete(values, "version")
+	original, err := json.Marshal(values)
+	if err != nil {
+		return DevCoreConfig{}, nil, version, err
+	}
+	for index := version; index < currentVersion; index++ {
+		if err := migrations[index].migrate(values); err != nil {
+			return DevCoreConfig{}, nil, version, fmt.Errorf("can not %s in configuration file %s: %w", migrations[index].description, file, err)
+		}
+	}
+
+	content, err = json.Marshal(values)
+	if err != nil {
+		return DevCoreConfig{}, nil, version, err
+	}
+	if bytes.Equal(content, original) {
+		version = currentVersion
+	}
+
+	values["version"] = currentVersion
+	content, err = json.Marshal(values)
+	if err != nil {
+		return DevCoreConfig{}, nil, version, err
+	}
+	read := DevCoreConfig{}
+	if err := json.Unmarshal(content, &read); err != nil {
+		return DevCoreConfig{}, nil, version, fmt.Errorf("can not read configuration file %s: %w", file, err)
+	}
+
+	all, err := read.Values()
+	if err != nil {
+		return DevCoreConfig{}, nil, version, err
+	}
+	return read, presentValues(all, values).(map[string]interface{}), version, nil
+}
+
+// presentValues returns the values whose keys are present in the raw values, as read from a file. Zero values present
+// in the file are kept, the keys the configuration doesn't know are dropped.
+func presentValues(values interface{}, raw interface{}) interface{} {
+	if object, isObject := values.(map[string]interface{}); isObject {
+		rawObject, _ := raw.(map[string]interface{})
+		present := make(map[string]interface{})
+		for key, value := range object {
+			if rawValue, found := rawObject[key]; found {
+				present[key] = presentValues(value, rawValue)
+			}
+		}
+		return present
+	}
+
+	if list, isList := values.([]interface{}); isList {
+		rawList, _ := raw.([]interface{})
+		if len(rawList) != len(list) {
+			return list
+		}
+		present := make([]interface{}, 0)
+		for index, element := range list {
+			present = append(present, presentValues(element, rawList[index]))
+		}
+		return present
+	}
+	return values
+}
+
+// removeEmptyValues removes the keys having an empty value from the object, and from the objects it contains.
+func removeEmptyValues(object map[string]interface{}) {
+	for key, value := range object {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			removeEmptyValues(value)
+		case []interface{}:
+			for _, element := range value {
+				if elementObject, isObject := element.(map[string]interface{}); isObject {
+					removeEmptyValues(elementObject)
+				}
+			}
+		}
+		if isEmpty(object[key]) {
+			delete(object, key)
+		}
+	}
+}
+
+// loadConfigFile reads the configuration file, returning the values it sets as well. An outdated file is backed up and
+// rewritten at the current version.
+func loadConfigFile() (DevCoreConfig, map[string]interface{}, error) {
+	lock, err := lockConfig()
+	if err != nil {
+		return DevCoreConfig{}, nil, err
+	}
+	defer lock.Unlock()
+
+	if _, err := os.Stat(configFile()); os.IsNotExist(err) && du.DryRun {
+		// The file has not been created because of the dry run mode
+		return DevCoreConfig{Version: currentVersion}, map[string]interface{}{"version": currentVersion}, nil
+	}
+
+	read, values, version, err := readConfig(configFile())
+	if err != nil {
+		return DevCoreConfig{}, nil, err
+	}
+
+	if version < currentVersion && du.DryRun {
+		du.Plan("back the configuration up to %s.v%d.bak and migrate %s to version %d", configFile(), version, configFile(), currentVersion)
+	} else if version < currentVersion {
+		backup := fmt.Sprintf("%s.v%d.bak", configFile(), version)
+		content, err := os.ReadFile(configFile())
+		if err != nil {
+			return DevCoreConfig{}, nil, err
+		}
+		if err := os.WriteFile(backup, content, 0600); err != nil {
+			return DevCoreConfig{}, nil, fmt.Errorf("can not back the configuration up before migrating it: %w", err)
+		}
+		if err := writeConfig(configFile(), values); err != nil {
+			return DevCoreConfig{}, nil, err
+		}
+	}
+	return read, values, nil
+}
+
+// saveConfig writes the changes made to the configuration since it has been loaded to the configuration file of the
+// user, on top of the changes other devcore processes made to it meanwhile. The settings the file doesn't set are only
+// written when changed, so that the lower layers keep setting them.
+func saveConfig(c *DevCoreConfig) error {
+	lock, err := lockConfig()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	theirs := map[string]interface{}{}
+	if _, err := os.Stat(configFile()); !os.IsNotExist(err) || !du.DryRun {
+		if _, theirs, _, err = readConfig(configFile()); err != nil {
+			return err
+		}
+	}
+	mine, err := c.Values()
+	if err != nil {
+		return err
+	}
+
+	merged := merge(loadedValues, mine, theirs)
+	// Saving again only writes the changes made from now on
+	loadedValues = mine
+	if reflect.DeepEqual(merged, theirs) {
+		return nil
+	}
+	if du.DryRun {
+		du.Plan("write %s, changing %s", configFile(), strings.Join(changedPaths(theirs, merged), ", "))
+		return nil
+	}
+
+	if _, err := Decode(merged); err != nil {
+		return fmt.Errorf("can not save the configuration: %w", err)
+	}
+	return writeConfig(configFile(), merged)
+}
+
+// writeConfig replaces the configuration file atomically, so that it is never found partially written. The values
+// are generic JSON values.
+func writeConfig(file string, values interface{}) error {
+	content, err := encodeConfig(file, values)
+	if err != nil {
+		return err
+	}
+
+	temporary, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary.Name())
+
+	_, err = temporary.Write(append(content, '\n'))
+	if err == nil {
+		err = temporary.Chmod(0600)
+	}
+	if err == nil {
+		err = temporary.Sync()
+	}
+	if closeErr := temporary.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(temporary.Name(), file)
+}
+
+// lockConfig acquires the lock serializing the changes of the configuration file between devcore processes.
+func lockConfig() (*du.FileLock, error) {
+	return du.LockFile(configFile()+".lock", func() {
+		fmt.Fprintln(os.Stderr, "Waiting for another devcore process changing the configuration")
+	})
+}
+
+// merge applies the changes made from base to mine on top of theirs. Objects are merged key by key and lists of named
+// elements, like contexts, element by element. Other values are replaced by mine when changed. Values missing from
+// theirs, because they come from another layer, only get the changes.
+func merge(base interface{}, mine interface{}, theirs interface{}) interface{} {
+	if reflect.DeepEqual(base, mine) {
+		return theirs
+	}
+
+	baseObject, _ := base.(map[string]interface{})
+	mineObject, isObject := mine.(map[string]interface{})
+	theirsObject, theirsIsObject := theirs.(map[string]interface{})
+	if isObject && (theirsIsObject || theirs == nil) {
+		merged := make(map[string]interface{})
+		for key, value := range theirsObject {
+			merged[key] = value
+		}
+		for key := range baseObject {
+			if _, kept := mineObject[key]; !kept {
+				delete(merged, key)
+			}
+		}
+		for key, value := range mineObject {
+			theirsValue, exists := theirsObject[key]
+			if exists || !reflect.DeepEqual(baseObject[key], value) {
+				merged[key] = merge(baseObject[key], value, theirsValue)
+			}
+		}
+		return merged
+	}
+
+	baseList, _ := base.([]interface{})
+	mineList, isList := mine.([]interface{})
+	theirsList, theirsIsList := theirs.([]interface{})
+	if isList && (theirsIsList || theirs == nil) && areNamed(baseList) && areNamed(mineList) && areNamed(theirsList) {
+		merged := make([]interface{}, 0)
+		for _, element := range theirsList {
+			name := elementName(element)
+			mineElement, kept := findNamed(mineList, name)
+			baseElement, existed := findNamed(baseList, name)
+			if !kept && existed {
+				continue
+			}
+			if kept {
+				element = merge(baseElement, mineElement, element)
+			}
+			merged = append(merged, element)
+		}
+		for _, element := range mineList {
+			if _, found := findNamed(theirsList, elementName(element)); found {
+				continue
+			}
+			if baseElement, existed := findNamed(baseList, elementName(element)); !existed {
+				merged = append(merged, element)
+			} else if !reflect.DeepEqual(baseElement, element) {
+				// The element comes from another layer, only its changes are kept
+				theirsElement := map[string]interface{}{"name": elementName(element)}
+				merged = append(merged, merge(baseElement, element, theirsElement))
+			}
+		}
+		return merged
+	}
+
+	return mine
+}
+
+// changedPaths lists the paths of the scalar values differing between the configurations.
+func changedPaths(before interface{}, after interface{}) []string {
+	values := make(map[string]interface{})
+	for _, setting := range flatten(before, "") {
+		if setting.Value != nil {
+			values[setting.Path] = setting.Value
+		}
+	}
+
+	paths := make([]string, 0)
+	for _, setting := range flatten(after, "") {
+		if setting.Value == nil {
+			continue
+		} else if value, found := values[setting.Path]; !found || !reflect.DeepEqual(value, setting.Value) {
+			paths = append(paths, setting.Path)
+		}
+		delete(values, setting.Path)
+	}
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// areNamed tells if the elements of the list are objects having a name.
+func areNamed(list []interface{}) bool {
+	for _, element := range list {
+		if elementName(element) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+func elementName(element interface{}) string {
+	if object, ok := element.(map[string]interface{}); ok {
+		name, _ := object["name"].(string)
+		return name
+	}
+	return ""
+}
+
+func findNamed(list []interface{}, name string) (interface{}, bool) {
+	for _, element := range list {
+		if elementName(element) == name {
+			return element, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// values parses generic JSON values.
+func values(t *testing.T, content string) interface{} {
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(content), &parsed); err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		mine     string
+		theirs   string
+		expected string
+	}{
+		{
+			name:     "unchanged",
+			base:     `{"projects-dir": "/src"}`,
+			mine:     `{"projects-dir": "/src"}`,
+			theirs:   `{"projects-dir": "/work"}`,
+			expected: `{"projects-dir": "/work"}`,
+		},
+		{
+			name:     "different keys changed",
+			base:     `{"projects-dir": "/src", "servers-dir": "/srv"}`,
+			mine:     `{"projects-dir": "/work", "servers-dir": "/srv"}`,
+			theirs:   `{"projects-dir": "/src", "servers-dir": "/opt/servers"}`,
+			expected: `{"projects-dir": "/work", "servers-dir": "/opt/servers"}`,
+		},
+		{
+			name:     "same key changed",
+			base:     `{"network": {"proxy": "", "retries": 3}}`,
+			mine:     `{"network": {"proxy": "http://proxy:3128", "retries": 3}}`,
+			theirs:   `{"network": {"proxy": "http://other:8080", "retries": 5}}`,
+			expected: `{"network": {"proxy": "http://proxy:3128", "retries": 5}}`,
+		},
+		{
+			name:     "key deleted while another is changed",
+			base:     `{"default-tools-version": {"kubectl": "1.23.0", "helm": "3.8.0"}}`,
+			mine:     `{"default-tools-version": {"helm": "3.8.0"}}`,
+			theirs:   `{"default-tools-version": {"kubectl": "1.23.0", "helm": "3.8.1"}}`,
+			expected: `{"default-tools-version": {"helm": "3.8.1"}}`,
+		},
+		{
+			name:     "contexts added concurrently",
+			base:     `{"contexts": [{"name": "api", "file": "/api.yml"}]}`,
+			mine:     `{"contexts": [{"name": "api", "file": "/api.yml"}, {"name": "db", "file": "/db.yml"}]}`,
+			theirs:   `{"contexts": [{"name": "api", "file": "/api.yml"}, {"name": "web", "file": "/web.yml"}]}`,
+			expected: `{"contexts": [{"name": "api", "file": "/api.yml"}, {"name": "web", "file": "/web.yml"}, {"name": "db", "file": "/db.yml"}]}`,
+		},
+		{
+			name:     "context edited concurrently",
+			base:     `{"contexts": [{"name": "api", "file": "/api.yml", "description": ""}]}`,
+			mine:     `{"contexts": [{"name": "api", "file": "/api.yml", "description": "The API"}]}`,
+			theirs:   `{"contexts": [{"name": "api", "file": "/v2/api.yml", "description": ""}]}`,
+			expected: `{"contexts": [{"name": "api", "file": "/v2/api.yml", "description": "The API"}]}`,
+		},
+		{
+			name:     "context deleted while edited by another process",
+			base:     `{"contexts": [{"name": "api", "file": "/api.yml"}, {"name": "web", "file": "/web.yml"}]}`,
+			mine:     `{"contexts": [{"name": "web", "file": "/web.yml"}]}`,
+			theirs:   `{"contexts": [{"name": "api", "file": "/v2/api.yml"}, {"name": "web", "file": "/web.yml"}]}`,
+			expected: `{"contexts": [{"name": "web", "file": "/web.yml"}]}`,
+		},
+		{
+			name:     "value from another layer changed",
+			base:     `{"network": {"proxy": "http://team:3128", "timeout": "30s"}}`,
+			mine:     `{"network": {"proxy": "http://mine:3128", "timeout": "30s"}}`,
+			theirs:   `{}`,
+			expected: `{"network": {"proxy": "http://mine:3128"}}`,
+		},
+		{
+			name:     "context from another layer changed",
+			base:     `{"contexts": [{"name": "team", "file": "/team.yml", "description": ""}]}`,
+			mine:     `{"contexts": [{"name": "team", "file": "/team.yml", "description": "Shared"}]}`,
+			theirs:   `{"contexts": []}`,
+			expected: `{"contexts": [{"name": "team", "description": "Shared"}]}`,
+		},
+		{
+			name:     "context from another layer unchanged",
+			base:     `{"contexts": [{"name": "team", "file": "/team.yml"}], "projects-dir": "/src"}`,
+			mine:     `{"contexts": [{"name": "team", "file": "/team.yml"}], "projects-dir": "/work"}`,
+			theirs:   `{"contexts": [], "projects-dir": "/src"}`,
+			expected: `{"contexts": [], "projects-dir": "/work"}`,
+		},
+	}
+
+	for _, test := range tests {
+		merged := merge(values(t, test.base), values(t, test.mine), values(t, test.theirs))
+		if expected := values(t, test.expected); !reflect.DeepEqual(merged, expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, expected, merged)
+		}
+	}
+}
+
+func TestReadConfigWithoutVersion(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{"projects-dir": "/src"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	read, present, version, err := readConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != currentVersion {
+		t.Errorf("Expected a file only missing its version not to need a migration, got version %d", version)
+	}
+	if read.ProjectsDir != "/src" || read.Version != currentVersion {
+		t.Errorf("Expected the file to be read at version %d, got %+v", currentVersion, read)
+	}
+	if expected := values(t, fmt.Sprintf(`{"projects-dir": "/src", "version": %d}`, currentVersion)); !reflect.DeepEqual(interface{}(present), expected) {
+		t.Errorf("Expected the values set by the file to be %v, got %v", expected, present)
+	}
+}
+
+func TestReadConfigPresentValues(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	content := fmt.Sprintf("version: %d\nnetwork:\n  retries: 0\nverification:\n  require-checksum: false\nunknown: true\n", currentVersion)
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, present, _, err := readConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := values(t, fmt.Sprintf(`{"network": {"retries": 0}, "verification": {"require-checksum": false}, "version": %d}`, currentVersion))
+	if !reflect.DeepEqual(interface{}(present), expected) {
+		t.Errorf("Expected the empty values set by the file to be kept, got %v", present)
+	}
+}
+
+func TestReadConfigRemovesEmptyValues(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	content := `{"version": 1, "proxy": "", "projects-dir": "/src", "verification": {"keyring": "", "require-checksum": false},
+		"docker-compose": {"current-context": "", "contexts": [{"name": "db", "description": "", "file": "/db.yml"}]}}`
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, present, version, err := readConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 1 {
+		t.Errorf("Expected a file with empty values to be migrated, got version %d", version)
+	}
+	expected := values(t, fmt.Sprintf(`{"projects-dir": "/src", "docker-compose": {"contexts": [{"name": "db", "file": "/db.yml"}]}, "version": %d}`, currentVersion))
+	if !reflect.DeepEqual(interface{}(present), expected) {
+		t.Errorf("Expected the empty values written by older versions to be removed, got %v", present)
+	}
+}
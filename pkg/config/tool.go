@@ -1,588 +1,601 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"sort"
+	"strings"
+	"text/template"
 
+	"io.twasyl/devcore/pkg/archive"
+	"io.twasyl/devcore/pkg/cache"
 	du "io.twasyl/devcore/pkg/utils"
+	"io.twasyl/devcore/pkg/version"
 )
 
+// Tool describes a tool that can be installed using `devcore tools install`. Tools are declared in manifests, see
+// tools.yaml for the ones shipped with devcore.
 type Tool struct {
-	Name            string
-	Description     string
-	CommandLineName string
-	DefaultVersion  string
-	OS              func() string
-	Install         func(version string) error
+	Name            string            `json:"name" yaml:"name"`
+	Description     string            `json:"description" yaml:"description"`
+	CommandLineName string            `json:"command-line-name" yaml:"command-line-name"`
+	DefaultVersion  string            `json:"default-version" yaml:"default-version"`
+	URL             string            `json:"url" yaml:"url"`
+	OS              map[string]string `json:"os,omitempty" yaml:"os,omitempty"`
+	Arch            map[string]string `json:"arch,omitempty" yaml:"arch,omitempty"`
+	Platforms       []string          `json:"platforms,omitempty" yaml:"platforms,omitempty"`
+	Archive         string            `json:"archive,omitempty" yaml:"archive,omitempty"`
+	Binary          string            `json:"binary,omitempty" yaml:"binary,omitempty"`
+	Home            string            `json:"home,omitempty" yaml:"home,omitempty"`
+	HomeEnv         string            `json:"home-env,omitempty" yaml:"home-env,omitempty"`
+	Checksums       map[string]string `json:"checksums,omitempty" yaml:"checksums,omitempty"`
+	ChecksumURL     string            `json:"checksum-url,omitempty" yaml:"checksum-url,omitempty"`
+	SignatureURL    string            `json:"signature-url,omitempty" yaml:"signature-url,omitempty"`
+	VersionArgs     []string          `json:"version-args,omitempty" yaml:"version-args,omitempty"`
+	VersionRegex    string            `json:"version-regex,omitempty" yaml:"version-regex,omitempty"`
+	Releases        ReleaseSource     `json:"releases,omitempty" yaml:"releases,omitempty"`
+}
+
+// toolTemplateData holds the values available in the templates of a tool definition.
+type toolTemplateData struct {
+	Version string
+	OS      string
+	Arch    string
+	URL     string
 }
 
 // DefaultSupportedTools represents the tools that can be installed using `devcore tools install` with their default
 // version.
 var DefaultSupportedTools []Tool
 
-func bat() Tool {
-	tool := Tool{
-		Name:            "bat",
-		Description:     "A cat like tool, but more powerful",
-		CommandLineName: "bat",
-		DefaultVersion:  "0.20.0",
-		OS: func() string {
-			if runtime.GOOS == "darwin" {
-				return "apple-darwin"
-			} else if runtime.GOOS == "windows" {
-				return "pc-windows-gnu"
-			} else {
-				return "unknown-linux-gnu"
-			}
-		},
-	}
-	tool.Install = func(version string) error {
-		var archive = filepath.Join(os.TempDir(), "bat.tar.gz")
-		osName := tool.OS()
-
-		err := du.DownloadFile(fmt.Sprintf("https://github.com/sharkdp/bat/releases/download/v%s/bat-v%s-x86_64-%s.tar.gz", version, version, osName), archive)
-		if err != nil {
-			return err
-		}
+// SupportedTools represents all the tools that can be installed, that is the DefaultSupportedTools as well as the ones
+// declared in the user's tool manifests.
+var SupportedTools []Tool
 
-		destinationDir := filepath.Join(os.TempDir(), "bat")
-		err = os.Mkdir(destinationDir, 0755)
-		if err != nil {
-			return err
-		}
+func init() {
+	tools, err := parseToolManifest("tools.yaml", defaultToolsManifest)
+	if err != nil {
+		panic(err)
+	}
+	DefaultSupportedTools = tools
+	SupportedTools = tools
+}
 
-		err = du.Expand(archive, destinationDir)
-		if err != nil {
-			return err
-		}
+// Platform identifies an operating system and an architecture, using the GOOS and GOARCH names.
+type Platform struct {
+	OS   string
+	Arch string
+}
 
-		err = os.Rename(filepath.Join(destinationDir, fmt.Sprintf("bat-v%s-x86_64-%s", version, osName), "bat"), fmt.Sprintf("/usr/local/bin/%s", tool.CommandLineName))
-		if err != nil {
-			return err
-		}
+// CurrentPlatform returns the platform devcore is running on.
+func CurrentPlatform() Platform {
+	return Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}
+}
 
-		err = os.Remove(archive)
-		if err != nil {
-			return err
-		}
+func (p Platform) String() string {
+	return fmt.Sprintf("%s/%s", p.OS, p.Arch)
+}
 
-		return os.RemoveAll(destinationDir)
+// OSName returns the name used by the tool's upstream for the operating system of the platform. A name mapped to the
+// whole platform (e.g. linux/arm64) takes precedence over the one mapped to the operating system only.
+func (t *Tool) OSName(platform Platform) string {
+	if name, exists := t.OS[platform.String()]; exists {
+		return name
+	} else if name, exists := t.OS[platform.OS]; exists {
+		return name
 	}
-	return tool
+	return platform.OS
 }
 
-func geckodriver() Tool {
-	tool := Tool{
-		Name:            "geckodriver",
-		Description:     "A driver to be used by Selenium",
-		CommandLineName: "geckodriver",
-		DefaultVersion:  "0.31.0",
-		OS: func() string {
-			if runtime.GOOS == "darwin" {
-				return "macos"
-			} else if runtime.GOOS == "linux" {
-				return "linux64"
-			} else {
-				return "win64"
-			}
-		},
+// ArchName returns the name used by the tool's upstream for the architecture of the platform. A name mapped to the
+// whole platform (e.g. darwin/arm64) takes precedence over the one mapped to the architecture only.
+func (t *Tool) ArchName(platform Platform) string {
+	if name, exists := t.Arch[platform.String()]; exists {
+		return name
+	} else if name, exists := t.Arch[platform.Arch]; exists {
+		return name
 	}
-	tool.Install = func(version string) error {
-		var archive = filepath.Join(os.TempDir(), "geckodriver.tar.gz")
+	return platform.Arch
+}
 
-		err := du.DownloadFile(fmt.Sprintf("https://github.com/mozilla/geckodriver/releases/download/v%s/geckodriver-v%s-%s.tar.gz", version, version, tool.OS()), archive)
-		if err != nil {
-			return err
+// SupportsPlatform indicates if the tool is built for the platform. Tools not declaring their platforms are assumed
+// to be built for all of them.
+func (t *Tool) SupportsPlatform(platform Platform) bool {
+	if len(t.Platforms) == 0 {
+		return true
+	}
+	for _, supported := range t.Platforms {
+		if supported == platform.String() {
+			return true
 		}
+	}
+	return false
+}
 
-		destinationDir := filepath.Join(os.TempDir(), "geckodriver")
-		err = os.Mkdir(destinationDir, 0755)
-		if err != nil {
-			return err
-		}
+func (t *Tool) templateData(version string, platform Platform) toolTemplateData {
+	return toolTemplateData{Version: version, OS: t.OSName(platform), Arch: t.ArchName(platform)}
+}
 
-		err = du.Expand(archive, destinationDir)
-		if err != nil {
-			return err
-		}
+func (t *Tool) render(text string, data toolTemplateData) (string, error) {
+	tmpl, err := template.New(t.Name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template for tool %s: %w", t.Name, err)
+	}
 
-		err = os.Rename(filepath.Join(destinationDir, "geckodriver"), fmt.Sprintf("/usr/local/bin/%s", tool.CommandLineName))
-		if err != nil {
-			return err
+	buffer := bytes.Buffer{}
+	err = tmpl.Execute(&buffer, data)
+	if err != nil {
+		return "", fmt.Errorf("invalid template for tool %s: %w", t.Name, err)
+	}
+	return buffer.String(), nil
+}
+
+func (t *Tool) validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("a tool has no name")
+	}
+	if t.CommandLineName == "" {
+		return fmt.Errorf("tool %s has no command-line-name", t.Name)
+	}
+	if t.URL == "" {
+		return fmt.Errorf("tool %s has no url", t.Name)
+	}
+	switch t.Archive {
+	case "":
+		if t.Home != "" || t.Binary != "" {
+			return fmt.Errorf("tool %s declares a home or a binary but no archive", t.Name)
 		}
+	case "zip", "tar", "tar.gz", "tgz", "tar.xz", "tar.bz2", "tar.zst":
+		if t.Binary == "" {
+			return fmt.Errorf("tool %s has no binary", t.Name)
+		}
+	default:
+		return fmt.Errorf("tool %s has an unknown archive type: %s", t.Name, t.Archive)
+	}
+	if t.HomeEnv != "" && t.Home == "" {
+		return fmt.Errorf("tool %s declares a home-env but no home", t.Name)
+	}
+	for _, platform := range t.Platforms {
+		if strings.Count(platform, "/") != 1 {
+			return fmt.Errorf("tool %s has an invalid platform, expecting os/arch: %s", t.Name, platform)
+		}
+	}
+	if _, err := regexp.Compile(t.VersionRegex); err != nil {
+		return fmt.Errorf("tool %s has an invalid version-regex: %w", t.Name, err)
+	}
+	return nil
+}
 
-		err = os.Remove(archive)
-		if err != nil {
-			return err
+// verification returns how the artifact of the given version, downloaded from url, is verified. A checksum pinned in
+// the tool definition for the artifact takes precedence over the one published upstream.
+func (t *Tool) verification(data toolTemplateData) (artifactVerification, error) {
+	verification := artifactVerification{URL: data.URL, Checksum: t.Checksums[path.Base(data.URL)]}
+
+	var err error
+	if t.ChecksumURL != "" {
+		if verification.ChecksumURL, err = t.render(t.ChecksumURL, data); err != nil {
+			return verification, err
+		}
+	}
+	if t.SignatureURL != "" {
+		if verification.SignatureURL, err = t.render(t.SignatureURL, data); err != nil {
+			return verification, err
 		}
+	}
+	return verification, nil
+}
 
-		return os.RemoveAll(destinationDir)
+// Install downloads the given version of the tool built for the platform, verifies it and installs it in its own
+// version directory. When the platform is the current one, the installed version becomes the active one. Builds for
+// other platforms are installed apart from the native versions and are never linked. The external commands, like the
+// verification of signatures, are run by the runner.
+//
+// The install is staged in a directory of its own and holds the tool's lock, so that concurrent installs don't
+// interfere. The version directory is moved into place once complete and, when anything fails or the install is
+// interrupted, every change is rolled back.
+func (t Tool) Install(runner du.Runner, version string, platform Platform) error {
+	if !t.SupportsPlatform(platform) {
+		return &ToolPlatformNotSupported{Name: t.Name, Platform: platform.String()}
 	}
 
-	return tool
-}
+	lock, err := t.lock()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
 
-func gh() Tool {
-	tool := Tool{
-		Name:            "gh",
-		Description:     "GitHub CLI tool for interacting with GitHub",
-		CommandLineName: "gh",
-		DefaultVersion:  "2.9.0",
-		OS: func() string {
-			if runtime.GOOS == "darwin" {
-				return "macOS"
-			} else {
-				return runtime.GOOS
-			}
-		},
+	versionDir := t.PlatformVersionDir(version, platform)
+	if _, err := os.Stat(versionDir); err == nil {
+		return fmt.Errorf("%s %s is already installed in %s", t.Name, version, versionDir)
 	}
-	tool.Install = func(version string) error {
-		var archive = filepath.Join(os.TempDir(), "gh.tar.gz")
-		osName := tool.OS()
 
-		err := du.DownloadFile(fmt.Sprintf("https://github.com/cli/cli/releases/download/v%s/gh_%s_%s_amd64.tar.gz", version, version, osName), archive)
-		if err != nil {
-			return err
-		}
+	data := t.templateData(version, platform)
+	url, err := t.render(t.URL, data)
+	if err != nil {
+		return err
+	}
+	data.URL = url
 
-		destinationDir := filepath.Join(os.TempDir(), "github")
-		err = os.Mkdir(destinationDir, 0755)
-		if err != nil {
-			return err
+	verification, err := t.verification(data)
+	if err != nil {
+		return err
+	}
+	if du.DryRun {
+		return t.planInstall(verification, data, version, platform)
+	}
+
+	tx := beginTransaction()
+	defer tx.rollback()
+
+	versionsDir := filepath.Dir(versionDir)
+	err = tx.apply(func() error {
+		return os.MkdirAll(versionsDir, 0755)
+	}, func() {
+		// Only succeeds for the directories in which no other version is installed
+		for dir := versionsDir; dir != filepath.Dir(t.VersionsDir()); dir = filepath.Dir(dir) {
+			os.Remove(dir)
 		}
+	})
+	if err != nil {
+		return err
+	}
 
-		err = du.Expand(archive, destinationDir)
-		if err != nil {
-			return err
+	var stagingDir string
+	err = tx.apply(func() (err error) {
+		stagingDir, err = os.MkdirTemp(versionsDir, ".install-")
+		return err
+	}, func() {
+		os.RemoveAll(stagingDir)
+	})
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stagingDir)
+
+	download := filepath.Join(stagingDir, path.Base(url))
+	err = verification.download(download)
+	if cache.IsNotCached(err) {
+		return err
+	} else if err != nil {
+		return fmt.Errorf("no build of %s %s could be downloaded for %s: %w", t.Name, version, platform, err)
+	}
+
+	err = verification.verify(runner, download)
+	if err != nil {
+		return err
+	}
+
+	stagedVersionDir := filepath.Join(stagingDir, version)
+	if t.Archive == "" {
+		err = t.installBinary(download, stagedVersionDir)
+	} else {
+		err = t.installArchive(download, data, stagingDir, stagedVersionDir)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = tx.apply(func() error {
+		return os.Rename(stagedVersionDir, versionDir)
+	}, func() {
+		os.RemoveAll(versionDir)
+	})
+	if err != nil {
+		return err
+	}
+
+	if platform != CurrentPlatform() {
+		tx.commit()
+		return nil
+	}
+
+	previousVersion := t.ActiveVersion()
+	err = tx.apply(func() error {
+		return t.use(version)
+	}, func() {
+		if previousVersion == "" || t.use(previousVersion) != nil {
+			t.removeLinks()
 		}
+	})
+	if err != nil {
+		return err
+	}
+
+	tx.commit()
+	return nil
+}
 
-		err = os.Rename(filepath.Join(destinationDir, fmt.Sprintf("gh_%s_%s_amd64", version, osName), "bin", "gh"), fmt.Sprintf("/usr/local/bin/%s", tool.CommandLineName))
+// planInstall prints the steps of the installation of the version of the tool, in dry run mode.
+func (t *Tool) planInstall(verification artifactVerification, data toolTemplateData, version string, platform Platform) error {
+	versionDir := t.PlatformVersionDir(version, platform)
+	download := filepath.Join(filepath.Dir(versionDir), ".install-*", path.Base(data.URL))
+	if err := verification.download(download); err != nil {
+		return err
+	}
+	if verification.Checksum != "" || verification.ChecksumURL != "" {
+		du.Plan("verify the checksum of %s", download)
+	}
+	if verification.SignatureURL != "" && Config.Verification.Keyring != "" {
+		du.Plan("verify the signature of %s", download)
+	}
+
+	if t.Archive == "" {
+		du.Plan("move %s to %s", download, filepath.Join(versionDir, t.CommandLineName))
+	} else if t.Home == "" {
+		binaryInArchive, err := t.render(t.Binary, data)
 		if err != nil {
 			return err
 		}
-
-		err = os.Remove(archive)
+		du.Plan("extract %s and move its %s to %s", download, binaryInArchive, filepath.Join(versionDir, t.CommandLineName))
+	} else {
+		homeInArchive, err := t.render(t.Home, data)
 		if err != nil {
 			return err
 		}
+		du.Plan("extract %s and move its %s to %s", download, homeInArchive, versionDir)
+	}
 
-		return os.RemoveAll(destinationDir)
+	if platform != CurrentPlatform() {
+		return nil
 	}
-	return tool
+	binary, err := t.BinaryPath(version)
+	if err != nil {
+		return err
+	}
+	du.Plan("link %s to %s", t.currentLink(), version)
+	du.Plan("link %s to %s", t.binaryLink(), binary)
+	return nil
 }
 
-func openshiftClient() Tool {
-	tool := Tool{
-		Name:            "openshift-client",
-		Description:     "Interact with Openshift in the CLI",
-		CommandLineName: "oc",
-		DefaultVersion:  "4.10.10",
-		OS: func() string {
-			if runtime.GOOS == "darwin" {
-				return "mac"
-			} else {
-				return runtime.GOOS
-			}
-		},
+// installBinary moves the downloaded binary to the version directory being staged.
+func (t *Tool) installBinary(binary string, versionDir string) error {
+	if err := os.Mkdir(versionDir, 0755); err != nil {
+		return err
 	}
-	tool.Install = func(version string) error {
-		var archive = filepath.Join(os.TempDir(), "openshift-client.tar.gz")
-		err := du.DownloadFile(fmt.Sprintf("https://mirror.openshift.com/pub/openshift-v4/x86_64/clients/ocp/%s/openshift-client-%s-%s.tar.gz", version, tool.OS(), version), archive)
-		if err != nil {
-			return err
-		}
 
-		destinationDir := filepath.Join(os.TempDir(), "openshift-client")
-		err = os.Mkdir(destinationDir, 0755)
-		if err != nil {
-			return err
-		}
+	destination := filepath.Join(versionDir, t.CommandLineName)
+	if err := du.MoveFile(binary, destination); err != nil {
+		return err
+	}
+	return os.Chmod(destination, 0755)
+}
 
-		err = du.Expand(archive, destinationDir)
-		if err != nil {
-			return err
-		}
+// installArchive expands the archive in the staging directory and moves either the tool's home directory or its binary
+// to the version directory being staged.
+func (t *Tool) installArchive(file string, data toolTemplateData, stagingDir string, versionDir string) error {
+	destinationDir := filepath.Join(stagingDir, "expanded")
+	err := os.Mkdir(destinationDir, 0755)
+	if err != nil {
+		return err
+	}
 
-		err = os.Rename(filepath.Join(destinationDir, "oc"), fmt.Sprintf("/usr/local/bin/%s", tool.CommandLineName))
+	err = archive.Extract(file, destinationDir, archive.Options{})
+	if err != nil {
+		return err
+	}
+
+	if t.Home == "" {
+		binaryInArchive, err := t.render(t.Binary, data)
 		if err != nil {
 			return err
 		}
+		return t.installBinary(filepath.Join(destinationDir, binaryInArchive), versionDir)
+	}
 
-		err = os.Remove(archive)
-		if err != nil {
-			return err
+	homeInArchive, err := t.render(t.Home, data)
+	if err != nil {
+		return err
+	}
+	return os.Rename(filepath.Join(destinationDir, homeInArchive), versionDir)
+}
+
+// lock acquires the lock serializing the changes made to the tool's installed versions.
+func (t *Tool) lock() (*du.FileLock, error) {
+	toolsDir := filepath.Dir(t.VersionsDir())
+	if !du.DryRun {
+		if err := os.MkdirAll(toolsDir, 0755); err != nil {
+			return nil, err
 		}
+	}
+
+	return du.LockFile(filepath.Join(toolsDir, fmt.Sprintf(".%s.lock", t.Name)), func() {
+		fmt.Println(fmt.Sprintf("Waiting for another devcore process changing %s", t.Name))
+	})
+}
+
+// VersionsDir returns the directory containing the installed versions of the tool.
+func (t *Tool) VersionsDir() string {
+	return filepath.Join(Config.Prefix(), "tools", t.Name)
+}
+
+// VersionDir returns the directory in which the given version of the tool is installed.
+func (t *Tool) VersionDir(version string) string {
+	return filepath.Join(t.VersionsDir(), version)
+}
 
-		return os.RemoveAll(destinationDir)
+// PlatformVersionDir returns the directory in which the given version of the tool built for the platform is installed.
+// Builds for other platforms than the current one are kept in a hidden directory named after their platform, e.g.
+// .platforms/linux-arm64, so that they are never listed, used or linked as native versions.
+func (t *Tool) PlatformVersionDir(version string, platform Platform) string {
+	if platform == CurrentPlatform() {
+		return t.VersionDir(version)
 	}
-	return tool
+	return filepath.Join(t.VersionsDir(), ".platforms", fmt.Sprintf("%s-%s", platform.OS, platform.Arch), version)
+}
+
+// currentLink returns the link pointing to the directory of the active version of the tool.
+func (t *Tool) currentLink() string {
+	return filepath.Join(t.VersionsDir(), "current")
 }
 
-func openshiftInstall() Tool {
-	tool := Tool{
-		Name:            "openshift-install",
-		Description:     "Openshift installer",
-		CommandLineName: "openshift-install",
-		DefaultVersion:  "4.9.12",
-		OS: func() string {
-			if runtime.GOOS == "darwin" {
-				return "mac"
-			} else {
-				return runtime.GOOS
-			}
-		},
+// binaryLink returns the link, in the bin directory, pointing to the binary of the active version of the tool.
+func (t *Tool) binaryLink() string {
+	return filepath.Join(Config.BinDir(), t.CommandLineName)
+}
+
+// BinaryPath returns the path of the binary of the given version of the tool.
+func (t *Tool) BinaryPath(version string) (string, error) {
+	if t.Home == "" {
+		return filepath.Join(t.VersionDir(version), t.CommandLineName), nil
 	}
-	tool.Install = func(version string) error {
-		var archive = filepath.Join(os.TempDir(), "openshift-install.tar.gz")
 
-		err := du.DownloadFile(fmt.Sprintf("https://mirror.openshift.com/pub/openshift-v4/x86_64/clients/ocp/%s/openshift-install-%s-%s.tar.gz", version, tool.OS(), version), archive)
-		if err != nil {
-			return err
-		}
+	binary, err := t.render(t.Binary, t.templateData(version, CurrentPlatform()))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(t.VersionDir(version), binary), nil
+}
 
-		destinationDir := filepath.Join(os.TempDir(), "openshift-install")
-		err = os.Mkdir(destinationDir, 0755)
-		if err != nil {
-			return err
-		}
+// IsInstalled indicates if the given version of the tool built for the platform is installed.
+func (t *Tool) IsInstalled(version string, platform Platform) bool {
+	info, err := os.Stat(t.PlatformVersionDir(version, platform))
+	return err == nil && info.IsDir()
+}
 
-		err = du.Expand(archive, destinationDir)
-		if err != nil {
-			return err
+// InstalledVersions lists the installed versions of the tool.
+func (t *Tool) InstalledVersions() ([]string, error) {
+	entries, err := os.ReadDir(t.VersionsDir())
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	versions := make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			versions = append(versions, entry.Name())
 		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return version.Compare(versions[i], versions[j]) < 0
+	})
+	return versions, nil
+}
 
-		err = os.Rename(filepath.Join(destinationDir, "openshift-install"), fmt.Sprintf("/usr/local/bin/%s", tool.CommandLineName))
-		if err != nil {
+// ActiveVersion returns the version of the tool currently in use, or an empty string if none is.
+func (t *Tool) ActiveVersion() string {
+	target, err := os.Readlink(t.currentLink())
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(target)
+}
+
+// Use makes the given installed version of the tool the active one by pointing the tool's links to it.
+func (t *Tool) Use(version string) error {
+	lock, err := t.lock()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	return t.use(version)
+}
+
+func (t *Tool) use(version string) error {
+	if !t.IsInstalled(version, CurrentPlatform()) {
+		return &ToolVersionNotInstalled{Name: t.Name, Version: version}
+	}
+
+	binary, err := t.BinaryPath(version)
+	if err != nil {
+		return err
+	}
+
+	if du.DryRun {
+		du.Plan("link %s to %s", t.currentLink(), version)
+		du.Plan("link %s to %s", t.binaryLink(), binary)
+		return nil
+	}
+
+	if err := os.MkdirAll(Config.BinDir(), 0755); err != nil {
+		return err
+	}
+
+	if err := replaceSymlink(version, t.currentLink()); err != nil {
+		return err
+	}
+	return replaceSymlink(binary, t.binaryLink())
+}
+
+// removeLinks removes the links pointing to the active version of the tool.
+func (t *Tool) removeLinks() error {
+	for _, link := range []string{t.binaryLink(), t.currentLink()} {
+		if du.DryRun {
+			du.Plan("remove %s", link)
+			continue
+		}
+		if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
 			return err
 		}
+	}
+	return nil
+}
 
-		err = os.Remove(archive)
-		if err != nil {
+// Uninstall removes the given version of the tool. When the version is the active one, the tool's links are removed
+// too.
+func (t *Tool) Uninstall(version string) error {
+	lock, err := t.lock()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if !t.IsInstalled(version, CurrentPlatform()) {
+		return &ToolVersionNotInstalled{Name: t.Name, Version: version}
+	}
+
+	if t.ActiveVersion() == version {
+		if err := t.removeLinks(); err != nil {
 			return err
 		}
+	}
 
-		return os.RemoveAll(destinationDir)
+	if du.DryRun {
+		du.Plan("remove %s", t.VersionDir(version))
+		return nil
+	}
+	if err := os.RemoveAll(t.VersionDir(version)); err != nil {
+		return err
 	}
-	return tool
+
+	// Only succeeds once the last version is gone
+	os.Remove(t.VersionsDir())
+	return nil
 }
 
-func init() {
+// replaceSymlink creates a symbolic link, atomically replacing any file already present at its location.
+func replaceSymlink(target string, link string) error {
+	temporaryLink := fmt.Sprintf("%s.%d.new", link, os.Getpid())
+	os.Remove(temporaryLink)
+	if err := os.Symlink(target, temporaryLink); err != nil {
+		return err
+	}
+
+	if err := os.Rename(temporaryLink, link); err != nil {
+		os.Remove(temporaryLink)
+		return err
+	}
+	return nil
+}
 
-	DefaultSupportedTools = []Tool{
-		bat(),
-		{
-			Name:            "dive",
-			Description:     "A tool for exploring container in depth",
-			CommandLineName: "dive",
-			DefaultVersion:  "0.10.0",
-			Install: func(version string) error {
-				var archive = filepath.Join(os.TempDir(), "dive.tar.gz")
-				err := du.DownloadFile(fmt.Sprintf("https://github.com/wagoodman/dive/releases/download/v%s/dive_%s_%s_amd64.tar.gz", version, version, runtime.GOOS), archive)
-				if err != nil {
-					return err
-				}
-
-				destinationDir := filepath.Join(os.TempDir(), "dive")
-				err = os.Mkdir(destinationDir, 0755)
-				if err != nil {
-					return err
-				}
-
-				err = du.Expand(archive, destinationDir)
-				if err != nil {
-					return err
-				}
-
-				err = os.Rename(filepath.Join(destinationDir, "dive"), "/usr/local/bin/dive")
-				if err != nil {
-					return err
-				}
-				err = os.Chmod("/usr/local/bin/dive", 0755)
-				if err != nil {
-					return err
-				}
-
-				err = os.Remove(archive)
-				if err != nil {
-					return err
-				}
-
-				return os.RemoveAll(destinationDir)
-			},
-		},
-		geckodriver(),
-		gh(),
-		{
-			Name:            "helm",
-			Description:     "The package manager for Kubernetes",
-			CommandLineName: "helm",
-			DefaultVersion:  "3.8.2",
-			Install: func(version string) error {
-				var archive = filepath.Join(os.TempDir(), "helm.tar.gz")
-				err := du.DownloadFile(fmt.Sprintf("https://get.helm.sh/helm-v%s-%s-amd64.tar.gz", version, runtime.GOOS), archive)
-				if err != nil {
-					return err
-				}
-
-				destinationDir := filepath.Join(os.TempDir(), "helm")
-				err = os.Mkdir(destinationDir, 0755)
-				if err != nil {
-					return err
-				}
-
-				err = du.Expand(archive, destinationDir)
-				if err != nil {
-					return err
-				}
-
-				err = os.Rename(filepath.Join(destinationDir, fmt.Sprintf("%s-amd64", runtime.GOOS), "helm"), "/usr/local/bin/helm")
-				if err != nil {
-					return err
-				}
-
-				err = os.Remove(archive)
-				if err != nil {
-					return err
-				}
-
-				return os.RemoveAll(destinationDir)
-			},
-		},
-		{
-			Name:            "jq",
-			Description:     "jq is a lightweight and flexible command-line JSON processor",
-			CommandLineName: "jq",
-			DefaultVersion:  "1.6",
-			OS: func() string {
-				if runtime.GOOS == "darwin" {
-					return "macOS"
-				} else {
-					return runtime.GOOS
-				}
-			},
-			Install: func(version string) error {
-				var jqDownloadFilename string
-				var destinationFile string
-				if runtime.GOOS == "darwin" {
-					jqDownloadFilename = "jq-osx-amd64"
-					destinationFile = "/usr/local/bin/jq"
-				} else if runtime.GOOS == "linux" {
-					jqDownloadFilename = "jq-linux64"
-					destinationFile = "/usr/local/bin/jq"
-				} else {
-					jqDownloadFilename = "jq-win64.exe"
-					destinationFile = "C:\\jq.exe"
-				}
-
-				return du.DownloadFile(fmt.Sprintf("https://github.com/stedolan/jq/releases/download/jq-%s/%s", version, jqDownloadFilename), destinationFile)
-			},
-		},
-		{
-			Name:            "kind",
-			Description:     "kind is a tool for running local Kubernetes clusters using Docker container \"nodes\"",
-			CommandLineName: "kind",
-			DefaultVersion:  "0.12.0",
-			Install: func(version string) error {
-				return du.DownloadFile(fmt.Sprintf("https://github.com/kubernetes-sigs/kind/releases/download/v%s/kind-%s-amd64", version, runtime.GOOS), "/usr/local/bin/kind")
-			},
-		},
-		{
-			Name:            "kubectl",
-			Description:     "The Kubernetes command-line tool",
-			CommandLineName: "kubectl",
-			DefaultVersion:  "1.23.6",
-			Install: func(version string) error {
-				return du.DownloadFile(fmt.Sprintf("https://dl.k8s.io/release/v%s/bin/%s/amd64/kubectl", version, runtime.GOOS), "/usr/local/bin/kubectl")
-			},
-		},
-		{
-			Name:            "kustomize",
-			Description:     "Kubernetes native configuration management",
-			CommandLineName: "kustomize",
-			DefaultVersion:  "4.5.4",
-			Install: func(version string) error {
-				var archive = filepath.Join(os.TempDir(), "kustomize.tar.gz")
-				err := du.DownloadFile(fmt.Sprintf("https://github.com/kubernetes-sigs/kustomize/releases/download/kustomize/v%s/kustomize_v%s_%s_amd64.tar.gz", version, version, runtime.GOOS), archive)
-				if err != nil {
-					return err
-				}
-
-				destinationDir := "/usr/local/bin"
-
-				err = du.Expand(archive, destinationDir)
-				if err != nil {
-					return err
-				}
-
-				err = os.Remove(archive)
-				if err != nil {
-					return err
-				}
-
-				return os.Chmod(filepath.Join(destinationDir, "kustomize"), 0755)
-			},
-		},
-		{
-			Name:            "maven",
-			Description:     "Apache Maven is a software project management and comprehension tool",
-			CommandLineName: "mvn",
-			DefaultVersion:  "3.8.5",
-			Install: func(version string) error {
-				var archive = filepath.Join(os.TempDir(), "maven.zip")
-				err := du.DownloadFile(fmt.Sprintf("https://dlcdn.apache.org/maven/maven-3/%s/binaries/apache-maven-%s-bin.zip", version, version), archive)
-				if err != nil {
-					return err
-				}
-
-				var destinationDir string
-				if runtime.GOOS == "darwin" {
-					destinationDir = "/Library/maven"
-				} else if runtime.GOOS == "linux" {
-					destinationDir = "/usr/local/maven"
-				} else {
-					destinationDir = "C:\\maven"
-				}
-
-				if _, err := os.Stat(destinationDir); os.IsNotExist(err) {
-					err = os.Mkdir(destinationDir, 0755)
-					if err != nil {
-						return err
-					}
-				}
-
-				err = du.Expand(archive, destinationDir)
-				if err != nil {
-					return err
-				}
-
-				err = os.Rename(filepath.Join(destinationDir, fmt.Sprintf("apache-maven-%s", version)), filepath.Join(destinationDir, version))
-				if err != nil {
-					return err
-				}
-
-				if runtime.GOOS == "darwin" {
-					mavenPathD := "/etc/paths.d/maven"
-					if _, err := os.Stat(mavenPathD); os.IsNotExist(err) {
-						if _, err := os.Create(mavenPathD); err != nil {
-							return err
-						}
-					}
-
-					if err := os.WriteFile(mavenPathD, []byte(fmt.Sprintf("/Library/maven/%s/bin/mvn", version)), 0755); err != nil {
-						return err
-					}
-				} else if runtime.GOOS == "linux" {
-					if err := os.Symlink(fmt.Sprintf("/usr/local/maven/%s/bin/mvn", version), "/usr/local/bin/mvn"); err != nil {
-						return err
-					}
-				}
-
-				return os.Remove(archive)
-			},
-		},
-		{
-			Name:            "minishift",
-			Description:     "Minishift is a tool that helps you run OpenShift locally by running a single-node OpenShift cluster inside a VM",
-			CommandLineName: "minishift",
-			DefaultVersion:  "1.34.3",
-			Install: func(version string) error {
-				var archive = filepath.Join(os.TempDir(), "minishift.tar.gz")
-				err := du.DownloadFile(fmt.Sprintf("https://github.com/minishift/minishift/releases/download/v%s/minishift-%s-%s-amd64.tgz", version, version, runtime.GOOS), archive)
-				if err != nil {
-					return err
-				}
-
-				destinationDir := filepath.Join(os.TempDir(), "minishift")
-				err = os.Mkdir(destinationDir, 0755)
-				if err != nil {
-					return err
-				}
-
-				err = du.Expand(archive, destinationDir)
-				if err != nil {
-					return err
-				}
-
-				err = os.Rename(filepath.Join(destinationDir, fmt.Sprintf("minishift-%s-%s-amd64", version, runtime.GOOS), "minishift"), "/usr/local/bin/minishift")
-				if err != nil {
-					return err
-				}
-				err = os.Chmod("/usr/local/bin/minishift", 0755)
-				if err != nil {
-					return err
-				}
-
-				err = os.Remove(archive)
-				if err != nil {
-					return err
-				}
-
-				return os.RemoveAll(destinationDir)
-			},
-		},
-		openshiftClient(),
-		openshiftInstall(),
-		{
-			Name:            "operator-sdk",
-			Description:     "The Operator SDK provides the tools to build, test, and package Operators",
-			CommandLineName: "operator-sdk",
-			DefaultVersion:  "1.9.0",
-			Install: func(version string) error {
-				return du.DownloadFile(fmt.Sprintf("https://github.com/operator-framework/operator-sdk/releases/download/v%s/operator-sdk_%s_amd64", version, runtime.GOOS), "/usr/local/bin/operator-sdk")
-			},
-		},
-		{
-			Name:            "terraform",
-			Description:     "Terraform is an open-source infrastructure as code software tool that enables you to safely and predictably create, change, and improve infrastructure",
-			CommandLineName: "terraform",
-			DefaultVersion:  "1.1.9",
-			Install: func(version string) error {
-				var archive = filepath.Join(os.TempDir(), "terraform.zip")
-				err := du.DownloadFile(fmt.Sprintf("https://releases.hashicorp.com/terraform/%s/terraform_%s_%s_amd64.zip", version, version, runtime.GOOS), archive)
-				if err != nil {
-					return err
-				}
-
-				destinationDir := filepath.Join(os.TempDir(), "terraform")
-				err = os.Mkdir(destinationDir, 0755)
-				if err != nil {
-					return err
-				}
-
-				err = du.Expand(archive, destinationDir)
-				if err != nil {
-					return err
-				}
-
-				err = os.Rename(filepath.Join(destinationDir, "terraform"), "/usr/local/bin/terraform")
-				if err != nil {
-					return err
-				}
-
-				err = os.Remove(archive)
-				if err != nil {
-					return err
-				}
-
-				return os.RemoveAll(destinationDir)
-			},
-		},
-		{
-			Name:            "yq",
-			Description:     "yq is a lightweight and portable command-line YAML, JSON and XML processor",
-			CommandLineName: "yq",
-			DefaultVersion:  "4.24.5",
-			Install: func(version string) error {
-				return du.DownloadFile(fmt.Sprintf("https://github.com/mikefarah/yq/releases/download/v%s/yq_%s_amd64", version, runtime.GOOS), "/usr/local/bin/yq")
-			},
-		},
+// ConfiguredVersion returns the version of the tool installed by default: the one configured in DefaultToolsVersion,
+// or the default version of the tool's definition.
+func (t *Tool) ConfiguredVersion() string {
+	if configured := Config.DefaultToolsVersion[t.Name]; configured != "" {
+		return configured
 	}
+	return t.DefaultVersion
 }
 
 func FindTool(name string) (Tool, error) {
-	for _, tool := range DefaultSupportedTools {
+	for _, tool := range SupportedTools {
 		if tool.Name == name {
 			return tool, nil
 		}
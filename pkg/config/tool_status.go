@@ -0,0 +1,149 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	du "io.twasyl/devcore/pkg/utils"
+	"io.twasyl/devcore/pkg/version"
+)
+
+const defaultVersionRegex = `\d+\.\d+(?:\.\d+)?`
+
+const (
+	ToolMissing  = "missing"
+	ToolMatching = "matching"
+	ToolOlder    = "older"
+	ToolNewer    = "newer"
+	ToolUnknown  = "unknown"
+)
+
+var errNoVersionFound = errors.New("no version found")
+
+// ToolStatus describes the version of a tool found on the system compared to the version configured for it.
+type ToolStatus struct {
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	Installed  string `json:"installed"`
+	Configured string `json:"configured"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
+
+// versionArgs returns the arguments making the tool print its version.
+func (t *Tool) versionArgs() []string {
+	if len(t.VersionArgs) == 0 {
+		return []string{"--version"}
+	}
+	return t.VersionArgs
+}
+
+// DetectVersion executes the given binary of the tool with the runner to get its version. The version is extracted
+// from the output using the version regex of the tool: the first group of the regex when it has one, the whole match
+// otherwise. The versions reported by the JDK are converted to the versioning scheme of its releases.
+func (t *Tool) DetectVersion(runner du.Runner, binary string) (string, error) {
+	expression := t.VersionRegex
+	if expression == "" {
+		expression = defaultVersionRegex
+	}
+
+	regex, err := regexp.Compile(expression)
+	if err != nil {
+		return "", fmt.Errorf("invalid version regex for tool %s: %w", t.Name, err)
+	}
+
+	result, err := runner.Output(context.Background(), du.Command{Name: binary, Args: t.versionArgs()})
+	if err != nil {
+		return "", err
+	}
+
+	match := regex.FindStringSubmatch(result.Stdout + result.Stderr)
+	if match == nil {
+		return "", fmt.Errorf("%w in the output of `%s %s`", errNoVersionFound, filepath.Base(binary), strings.Join(t.versionArgs(), " "))
+	}
+
+	detected := match[0]
+	for _, group := range match[1:] {
+		if group != "" {
+			detected = group
+			break
+		}
+	}
+
+	detected = strings.TrimPrefix(detected, "v")
+	if t.Name == JDKTool().Name {
+		detected = javaVersion(detected)
+	}
+	return detected, nil
+}
+
+// FindBinary looks for the tool's binary in the PATH, then in the bin directory of devcore.
+func (t *Tool) FindBinary() (string, error) {
+	if binary, err := exec.LookPath(t.CommandLineName); err == nil {
+		return binary, nil
+	}
+
+	binary := t.binaryLink()
+	if _, err := os.Stat(binary); err != nil {
+		return "", err
+	}
+	return binary, nil
+}
+
+// Status compares the version of the tool found on the system to the one configured in DefaultToolsVersion.
+func (t *Tool) Status(runner du.Runner) ToolStatus {
+	status := ToolStatus{Name: t.Name, Configured: t.ConfiguredVersion()}
+
+	binary, err := t.FindBinary()
+	if err != nil {
+		status.Status = ToolMissing
+		return status
+	}
+	status.Path = binary
+
+	status.Installed, err = t.DetectVersion(runner, binary)
+	if err != nil {
+		status.Status = ToolUnknown
+		status.Error = err.Error()
+		return status
+	}
+
+	switch version.Compare(status.Installed, status.Configured) {
+	case -1:
+		status.Status = ToolOlder
+	case 1:
+		status.Status = ToolNewer
+	default:
+		status.Status = ToolMatching
+	}
+	return status
+}
+
+// CheckInstallation executes the freshly installed version of the tool to confirm it works. An error is returned when
+// the binary can not be executed at all, for instance when it has been built for another platform. When it runs but
+// doesn't report the expected version, a warning is returned.
+func (t *Tool) CheckInstallation(runner du.Runner, installedVersion string) (warning string, err error) {
+	binary, err := t.BinaryPath(installedVersion)
+	if err != nil {
+		return "", err
+	}
+
+	detected, err := t.DetectVersion(runner, binary)
+	var exitError *du.ExitError
+	if errors.As(err, &exitError) || errors.Is(err, errNoVersionFound) {
+		return err.Error(), nil
+	} else if err != nil {
+		return "", err
+	}
+
+	if version.Compare(detected, installedVersion) != 0 {
+		return fmt.Sprintf("%s reports version %s instead of %s", t.Name, detected, installedVersion), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+
+	du "io.twasyl/devcore/pkg/utils"
+	"io.twasyl/devcore/pkg/utils/utilstest"
+	"io.twasyl/devcore/pkg/version"
+)
+
+func TestDetectVersion(t *testing.T) {
+	tool := Tool{Name: "kubectl", VersionArgs: []string{"version", "--client"}, VersionRegex: `GitVersion:"v([^"]+)"`}
+	fake := utilstest.NewFakeRunner().
+		On("/bin/kubectl version --client", du.Result{Stdout: `Client Version: version.Info{GitVersion:"v1.24.3"}`})
+
+	detected, err := tool.DetectVersion(fake, "/bin/kubectl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detected != "1.24.3" {
+		t.Errorf("Expected version 1.24.3, got %s", detected)
+	}
+
+}
+
+func TestDetectJDKVersion(t *testing.T) {
+	tests := []struct {
+		output   string
+		release  string
+		expected string
+	}{
+		{`openjdk version "1.8.0_382"` + "\nOpenJDK Runtime Environment (Temurin)(build 1.8.0_382-b05)", "8.0.382+5", "8.0.382"},
+		{`openjdk version "11.0.20" 2023-07-18`, "11.0.20+8", "11.0.20"},
+		{`openjdk version "17.0.8" 2023-07-18`, "17.0.8+7", "17.0.8"},
+	}
+
+	tool := JDKTool()
+	for _, test := range tests {
+		fake := utilstest.NewFakeRunner().On("/jdk/bin/java -version", du.Result{Stderr: test.output})
+
+		detected, err := tool.DetectVersion(fake, "/jdk/bin/java")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if detected != test.expected {
+			t.Errorf("Expected version %s to be detected, got %s", test.expected, detected)
+		}
+		if version.Compare(detected, test.release) != 0 {
+			t.Errorf("Expected version %s to match the release %s", detected, test.release)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+var errInterrupted = errors.New("interrupted")
+
+// transaction records how to undo the changes made while installing something, so that a failed or interrupted
+// install leaves nothing behind. An interruption (Ctrl-C or SIGTERM) rolls the changes back before exiting.
+type transaction struct {
+	mutex   sync.Mutex
+	undo    []func()
+	ended   bool
+	signals chan os.Signal
+}
+
+func beginTransaction() *transaction {
+	tx := &transaction{signals: make(chan os.Signal, 1)}
+	signal.Notify(tx.signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		if _, interrupted := <-tx.signals; interrupted {
+			tx.rollback()
+			os.Exit(130)
+		}
+	}()
+	return tx
+}
+
+// apply makes a change and records how to undo it. The change is not made when the transaction has already ended,
+// which happens when it is interrupted.
+func (tx *transaction) apply(change func() error, undo func()) error {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+
+	if tx.ended {
+		return errInterrupted
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	tx.undo = append(tx.undo, undo)
+	return nil
+}
+
+// commit ends the transaction, keeping its changes.
+func (tx *transaction) commit() {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+
+	tx.end()
+}
+
+// rollback ends the transaction, undoing its changes in reverse order. It does nothing once the transaction has ended.
+func (tx *transaction) rollback() {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+
+	if tx.ended {
+		return
+	}
+	for index := len(tx.undo) - 1; index >= 0; index-- {
+		tx.undo[index]()
+	}
+	tx.end()
+}
+
+func (tx *transaction) end() {
+	if !tx.ended {
+		tx.ended = true
+		signal.Stop(tx.signals)
+		close(tx.signals)
+	}
+}
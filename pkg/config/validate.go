@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Validate checks that the configuration is consistent and that the files it references still exist. It returns the
+// problems found, if any.
+func (c *DevCoreConfig) Validate() []error {
+	problems := make([]error, 0)
+	missing := func(what string, file string) {
+		if file == "" {
+			return
+		}
+		if _, err := os.Stat(file); err != nil {
+			problems = append(problems, fmt.Errorf("%s %s doesn't exist", what, file))
+		}
+	}
+
+	if c.CurrentProfile != "" {
+		if _, err := c.FindProfileByName(c.CurrentProfile); err != nil {
+			problems = append(problems, fmt.Errorf("current profile: %w", err))
+		}
+	}
+
+	for _, context := range c.DockerCompose.Contexts {
+		missing(fmt.Sprintf("compose file of the docker compose context %s:", context.Name), context.File)
+		if !IsStopPolicy(context.StopPolicy) {
+			problems = append(problems, fmt.Errorf("stop policy of the docker compose context %s: unknown policy %s, expected one of %s", context.Name, context.StopPolicy, strings.Join(StopPolicies, ", ")))
+		}
+	}
+	if c.DockerCompose.CurrentContext != "" {
+		if _, err := c.DockerCompose.FindContextByName(c.DockerCompose.CurrentContext); err != nil {
+			problems = append(problems, fmt.Errorf("current docker compose context: %w", err))
+		}
+	}
+
+	for _, context := range c.Jenkins.Contexts {
+		missing(fmt.Sprintf("war of the Jenkins context %s:", context.Name), context.War)
+		missing(fmt.Sprintf("JENKINS_HOME of the Jenkins context %s:", context.Name), context.JenkinsHome)
+		missing(fmt.Sprintf("JAVA_HOME of the Jenkins context %s:", context.Name), context.JavaHome)
+	}
+	if c.Jenkins.CurrentContext != "" {
+		if _, err := c.Jenkins.FindContextByName(c.Jenkins.CurrentContext); err != nil {
+			problems = append(problems, fmt.Errorf("current Jenkins context: %w", err))
+		}
+	}
+
+	for name := range c.DefaultToolsVersion {
+		if _, err := FindTool(name); err != nil {
+			problems = append(problems, fmt.Errorf("default-tools-version: %w", err))
+		}
+	}
+
+	for _, catalog := range c.ToolCatalogs {
+		missing("tool catalog", catalog)
+	}
+	missing("CA bundle", c.Network.CABundle)
+
+	if c.Network.Retries != nil && *c.Network.Retries < 0 {
+		problems = append(problems, fmt.Errorf("network retries can not be negative"))
+	}
+
+	return problems
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path"
+
+	du "io.twasyl/devcore/pkg/utils"
+)
+
+// Verification describes how downloaded artifacts are verified before being installed.
+type Verification struct {
+	// Keyring is the GPG keyring holding the public keys used to check the signature of artifacts. Signatures are not
+	// checked when empty.
+	Keyring string `json:"keyring"`
+	// RequireChecksum makes installs fail when no checksum is known for an artifact.
+	RequireChecksum bool `json:"require-checksum"`
+}
+
+// artifactVerification holds what is known to verify an artifact downloaded from URL. The expected checksum is either
+// pinned or downloaded from ChecksumURL.
+type artifactVerification struct {
+	URL          string
+	Checksum     string
+	ChecksumURL  string
+	SignatureURL string
+}
+
+// pinnedSHA256 returns the pinned checksum when it is a SHA-256 digest, which is how the download cache stores files.
+func (v *artifactVerification) pinnedSHA256() string {
+	if len(v.Checksum) == sha256.Size*2 {
+		return v.Checksum
+	}
+	return ""
+}
+
+// download fetches the artifact, from the download cache when possible, to the given file.
+func (v *artifactVerification) download(artifact string) error {
+	return Config.Cache().Fetch(v.URL, v.pinnedSHA256(), artifact)
+}
+
+// verify checks the checksum and the signature of the downloaded artifact, the signature being checked by a process run
+// by the runner. The artifact is removed, from the disk and the download cache, when it doesn't pass the verification.
+func (v *artifactVerification) verify(runner du.Runner, artifact string) error {
+	err := v.verifyChecksum(artifact)
+	if err == nil {
+		err = v.verifySignature(runner, artifact)
+	}
+
+	if err != nil {
+		os.Remove(artifact)
+		Config.Cache().Evict(v.URL)
+	}
+	return err
+}
+
+func (v *artifactVerification) verifyChecksum(artifact string) error {
+	checksum := v.Checksum
+	if checksum == "" && v.ChecksumURL != "" {
+		content, err := fetchContent(v.ChecksumURL)
+		if err != nil {
+			return err
+		}
+
+		checksum, err = du.FindChecksum(content, path.Base(v.URL))
+		if err != nil {
+			return err
+		}
+	}
+
+	if checksum == "" {
+		if Config.Verification.RequireChecksum {
+			return fmt.Errorf("no checksum known for %s", v.URL)
+		}
+		fmt.Printf("Warning: no checksum known for %s, skipping verification\n", v.URL)
+		return nil
+	}
+
+	return du.VerifyChecksum(artifact, checksum)
+}
+
+func (v *artifactVerification) verifySignature(runner du.Runner, artifact string) error {
+	if v.SignatureURL == "" || Config.Verification.Keyring == "" {
+		return nil
+	}
+
+	signature, err := os.CreateTemp("", "devcore-*.asc")
+	if err != nil {
+		return err
+	}
+	signature.Close()
+	defer os.Remove(signature.Name())
+
+	if err := Config.Cache().Fetch(v.SignatureURL, "", signature.Name()); err != nil {
+		return err
+	}
+	return du.VerifySignature(runner, artifact, signature.Name(), Config.Verification.Keyring)
+}
+
+// fetchContent returns the content of a small file, like a checksum file. The file is downloaded again unless offline,
+// and kept in the download cache so that it is available offline.
+func fetchContent(url string) ([]byte, error) {
+	if !Offline {
+		if err := Config.Cache().Evict(url); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.CreateTemp("", "devcore-*")
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	if err := Config.Cache().Fetch(url, "", file.Name()); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(file.Name())
+}
@@ -0,0 +1,163 @@
+// Package output prints the results of the listing and status commands in the format chosen with the --output flag:
+// a table, a wide table with more columns, JSON, YAML or a Go template. The JSON and YAML outputs, as well as the
+// templates, use the field names of the JSON representation of the printed values, which are kept stable.
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	Table      = "table"
+	Wide       = "wide"
+	JSON       = "json"
+	YAML       = "yaml"
+	GoTemplate = "go-template"
+)
+
+// Format is an output format, as given to the --output flag. It implements pflag.Value.
+type Format struct {
+	Name string
+	// Template is the template of the go-template format.
+	Template *template.Template
+	text     string
+}
+
+// Selected is the format chosen with the --output flag.
+var Selected = Format{Name: Table}
+
+func (f *Format) String() string {
+	if f.Name == GoTemplate {
+		return fmt.Sprintf("%s=%s", GoTemplate, f.text)
+	}
+	return f.Name
+}
+
+func (f *Format) Set(value string) error {
+	switch {
+	case value == Table || value == Wide || value == JSON || value == YAML:
+		*f = Format{Name: value}
+	case strings.HasPrefix(value, GoTemplate+"="):
+		text := strings.TrimPrefix(value, GoTemplate+"=")
+		parsed, err := template.New("output").Funcs(functions).Parse(text)
+		if err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+		*f = Format{Name: GoTemplate, Template: parsed, text: text}
+	default:
+		return errors.New(fmt.Sprintf("unknown output format '%s', expected one of table, wide, json, yaml or go-template=<template>", value))
+	}
+	return nil
+}
+
+func (f *Format) Type() string {
+	return "format"
+}
+
+// IsTable tells if the format is table or wide, the formats meant to be read by people.
+func (f *Format) IsTable() bool {
+	return f.Name == Table || f.Name == Wide
+}
+
+// functions are the functions available in templates, besides the predefined ones.
+var functions = template.FuncMap{
+	"json": func(value interface{}) (string, error) {
+		content, err := json.Marshal(value)
+		return string(content), err
+	},
+	"join": strings.Join,
+}
+
+// Column is a column of the table and wide formats.
+type Column struct {
+	Header string
+	// Wide columns are only printed in the wide format.
+	Wide bool
+	// Value returns the content of the cell of the item.
+	Value func(item interface{}) string
+}
+
+// Printer prints values in the selected format.
+type Printer struct {
+	// Columns are printed for each element of the value, which is a slice, in the table and wide formats.
+	Columns []Column
+	// Text prints the value in the table and wide formats, for the values which are not lists.
+	Text func(w io.Writer, wide bool) error
+}
+
+// Print prints the value in the selected format. In the JSON and YAML formats, and in templates, the value is seen as
+// its JSON representation: the names of the fields are the ones of their JSON tags.
+func (p Printer) Print(w io.Writer, value interface{}) error {
+	switch Selected.Name {
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case YAML:
+		values, err := genericValues(value)
+		if err != nil {
+			return err
+		}
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(values); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case GoTemplate:
+		values, err := genericValues(value)
+		if err != nil {
+			return err
+		}
+		return Selected.Template.Execute(w, values)
+	}
+
+	wide := Selected.Name == Wide
+	if p.Text != nil {
+		return p.Text(w, wide)
+	}
+	return p.printTable(w, value, wide)
+}
+
+func (p Printer) printTable(w io.Writer, value interface{}, wide bool) error {
+	columns := make([]Column, 0)
+	headers := make([]string, 0)
+	for _, column := range p.Columns {
+		if wide || !column.Wide {
+			columns = append(columns, column)
+			headers = append(headers, column.Header)
+		}
+	}
+
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(headers, "\t"))
+	items := reflect.ValueOf(value)
+	for index := 0; index < items.Len(); index++ {
+		cells := make([]string, 0)
+		for _, column := range columns {
+			cells = append(cells, column.Value(items.Index(index).Interface()))
+		}
+		fmt.Fprintln(writer, strings.Join(cells, "\t"))
+	}
+	return writer.Flush()
+}
+
+// genericValues returns the JSON representation of the value as maps, slices and scalars.
+func genericValues(value interface{}) (interface{}, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var values interface{}
+	err = json.Unmarshal(content, &values)
+	return values, err
+}
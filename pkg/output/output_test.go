@@ -0,0 +1,60 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+type item struct {
+	Name        string `json:"name"`
+	JenkinsHome string `json:"jenkins-home"`
+}
+
+var printer = Printer{
+	Columns: []Column{
+		{Header: "NAME", Value: func(i interface{}) string { return i.(item).Name }},
+		{Header: "JENKINS HOME", Wide: true, Value: func(i interface{}) string { return i.(item).JenkinsHome }},
+	},
+}
+
+func TestPrint(t *testing.T) {
+	items := []item{{"dev", "/var/jenkins"}, {"release", ""}}
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"table", "NAME\ndev\nrelease\n"},
+		{"wide", "NAME     JENKINS HOME\ndev      /var/jenkins\nrelease  \n"},
+		{"json", "[\n  {\n    \"name\": \"dev\",\n    \"jenkins-home\": \"/var/jenkins\"\n  },\n  {\n    \"name\": \"release\",\n    \"jenkins-home\": \"\"\n  }\n]\n"},
+		{"yaml", "- jenkins-home: /var/jenkins\n  name: dev\n- jenkins-home: \"\"\n  name: release\n"},
+		{`go-template={{range .}}{{.name}}:{{index . "jenkins-home"}},{{end}}`, "dev:/var/jenkins,release:,"},
+	}
+
+	defer func() {
+		Selected = Format{Name: Table}
+	}()
+	for _, test := range tests {
+		if err := Selected.Set(test.format); err != nil {
+			t.Fatal(err)
+		}
+
+		output := &bytes.Buffer{}
+		if err := printer.Print(output, items); err != nil {
+			t.Errorf("Printing in %s failed: %s", test.format, err)
+		} else if output.String() != test.expected {
+			t.Errorf("Printing in %s gave %q, expected %q", test.format, output.String(), test.expected)
+		}
+	}
+}
+
+func TestSetInvalidFormat(t *testing.T) {
+	format := Format{Name: Table}
+	for _, value := range []string{"xml", "go-template={{.name", "go-template"} {
+		if err := format.Set(value); err == nil {
+			t.Errorf("Expected %s to be rejected", value)
+		}
+	}
+	if format.Name != Table {
+		t.Errorf("Expected the format to be kept, got %s", format.Name)
+	}
+}
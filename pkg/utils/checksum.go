@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// FileChecksum computes the hex encoded digest of a file. The algorithm is either sha256 or sha512.
+func FileChecksum(file string, algorithm string) (string, error) {
+	var h hash.Hash
+	switch algorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return "", fmt.Errorf("unsupported checksum algorithm: %s", algorithm)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyChecksum checks that the digest of the file is the expected one. The algorithm, sha256 or sha512, is deduced
+// from the length of the expected digest.
+func VerifyChecksum(file string, expected string) error {
+	expected = strings.ToLower(strings.TrimSpace(expected))
+
+	var algorithm string
+	switch len(expected) {
+	case sha256.Size * 2:
+		algorithm = "sha256"
+	case sha512.Size * 2:
+		algorithm = "sha512"
+	default:
+		return fmt.Errorf("invalid checksum: %s", expected)
+	}
+
+	actual, err := FileChecksum(file, algorithm)
+	if err != nil {
+		return err
+	}
+
+	if actual != expected {
+		return fmt.Errorf("%s checksum mismatch for %s: expected %s, got %s", algorithm, path.Base(file), expected, actual)
+	}
+	return nil
+}
+
+// FindChecksum extracts the digest of the given file name from the content of a checksum file. Both files listing
+// several digests (`<digest>  <file name>` on each line) and files containing only one digest are supported.
+func FindChecksum(content []byte, fileName string) (string, error) {
+	lines := make([][]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+			lines = append(lines, fields)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	if len(lines) == 1 && len(lines[0]) == 1 {
+		return lines[0][0], nil
+	}
+
+	for _, fields := range lines {
+		if len(fields) < 2 {
+			continue
+		}
+		name := strings.TrimPrefix(fields[len(fields)-1], "*")
+		if path.Base(name) == fileName {
+			return fields[0], nil
+		}
+	}
+	return "", fmt.Errorf("no checksum found for %s", fileName)
+}
+
+// VerifySignature checks the detached GPG signature of a file using the public keys of the given keyring. gpg is run
+// by the runner.
+func VerifySignature(runner Runner, file string, signature string, keyring string) error {
+	command := Command{Name: "gpg", Args: []string{"--batch", "--no-default-keyring", "--keyring", keyring, "--verify", signature, file}}
+	result, err := runner.Output(context.Background(), command)
+	var exitError *ExitError
+	if errors.As(err, &exitError) {
+		return fmt.Errorf("invalid signature for %s: %s", path.Base(file), strings.TrimSpace(result.Stdout+result.Stderr))
+	}
+	return err
+}
@@ -0,0 +1,48 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// DryRun makes devcore print the external commands, downloads and file system changes it would make instead of making
+// them. It is set by the --dry-run flag.
+var DryRun = false
+
+// DryRunOutput receives the steps planned in dry run mode.
+var DryRunOutput io.Writer = os.Stdout
+
+// Plan prints a step that is not made because of the dry run mode.
+func Plan(format string, args ...interface{}) {
+	fmt.Fprintf(DryRunOutput, "[dry-run] %s\n", fmt.Sprintf(format, args...))
+}
+
+// DryRunner prints the commands instead of running them. Their outputs are empty.
+type DryRunner struct{}
+
+func (r *DryRunner) Run(ctx context.Context, command Command) (Result, error) {
+	Plan("run %s", describe(command))
+	return Result{}, nil
+}
+
+func (r *DryRunner) Output(ctx context.Context, command Command) (Result, error) {
+	Plan("run %s", describe(command))
+	return Result{}, nil
+}
+
+func (r *DryRunner) Start(command Command) (int, error) {
+	Plan("start %s", describe(command))
+	return 0, nil
+}
+
+// describe returns the command line of the command, with its additional environment and its working directory.
+func describe(command Command) string {
+	description := strings.Join(append(append([]string{}, command.Env...), command.Line()), " ")
+	if command.Dir != "" {
+		description += " in " + command.Dir
+	}
+	return description
+}
@@ -0,0 +1,211 @@
+package pkg
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// RewriteRule makes the URLs starting with Prefix be downloaded from the same path under Replacement, typically an
+// internal mirror of an upstream site.
+type RewriteRule struct {
+	Prefix      string
+	Replacement string
+}
+
+var rewriteRules []RewriteRule
+
+var httpClient = &http.Client{}
+
+// SetRewriteRules defines the rules applied to the URLs of all downloads.
+func SetRewriteRules(rules []RewriteRule) {
+	rewriteRules = rules
+}
+
+// RewriteURL applies the rewrite rules to the URL. When several rules match, the one having the longest prefix wins.
+func RewriteURL(rawURL string) string {
+	var matching *RewriteRule
+	for index, rule := range rewriteRules {
+		if strings.HasPrefix(rawURL, rule.Prefix) && (matching == nil || len(rule.Prefix) > len(matching.Prefix)) {
+			matching = &rewriteRules[index]
+		}
+	}
+
+	if matching == nil {
+		return rawURL
+	}
+	return matching.Replacement + strings.TrimPrefix(rawURL, matching.Prefix)
+}
+
+// HTTPSettings tunes the HTTP client used for all downloads.
+type HTTPSettings struct {
+	// Proxy is the URL of the HTTP proxy. Without proxy, the usual HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment
+	// variables apply.
+	Proxy string
+	// CABundle is a PEM file holding certificate authorities to trust in addition to the system ones.
+	CABundle string
+	// Timeout is how long to wait for a connection, a response or the next bytes of a download.
+	Timeout time.Duration
+	// Retries is the number of times a download failing with a transient error is attempted again.
+	Retries int
+	// Tokens are the bearer tokens sent to hosts, keyed by host name.
+	Tokens map[string]string
+}
+
+// DefaultHTTPSettings are the settings used until ConfigureHTTP is called.
+var DefaultHTTPSettings = HTTPSettings{Timeout: 30 * time.Second, Retries: 3}
+
+var httpSettings = DefaultHTTPSettings
+
+// ConfigureHTTP sets the proxy, the additional certificate authorities, the timeout, the retries and the tokens used
+// for all downloads.
+func ConfigureHTTP(settings HTTPSettings) error {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if settings.Proxy != "" {
+		proxyURL, err := url.Parse(settings.Proxy)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL %s: %w", settings.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if settings.CABundle != "" {
+		pem, err := os.ReadFile(settings.CABundle)
+		if err != nil {
+			return fmt.Errorf("can not read the CA bundle: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificate found in the CA bundle %s", settings.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	if settings.Timeout > 0 {
+		transport.DialContext = (&net.Dialer{Timeout: settings.Timeout, KeepAlive: 30 * time.Second}).DialContext
+		transport.TLSHandshakeTimeout = settings.Timeout
+		transport.ResponseHeaderTimeout = settings.Timeout
+	}
+
+	httpClient = &http.Client{Transport: transport}
+	httpSettings = settings
+	return nil
+}
+
+// newRequest creates a GET request for the URL once rewritten. The token of the host, if any, is sent over HTTPS. The
+// returned cancel function must be called once the response is consumed.
+func newRequest(rawURL string) (*http.Request, context.CancelFunc, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, RewriteURL(rawURL), nil)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	if token, ok := httpSettings.Tokens[req.URL.Hostname()]; ok && token != "" && req.URL.Scheme == "https" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, cancel, nil
+}
+
+// statusError is returned when a server answers with an unexpected HTTP status.
+type statusError struct {
+	URL    string
+	Status string
+	Code   int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status for %s: %s", e.URL, e.Status)
+}
+
+var errStalled = errors.New("download stalled")
+
+// isTransient tells if a failed download may succeed when attempted again.
+func isTransient(err error) bool {
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.Code >= http.StatusInternalServerError || status.Code == http.StatusTooManyRequests
+	}
+
+	var dnsError *net.DNSError
+	if errors.As(err, &dnsError) {
+		return dnsError.IsTimeout || dnsError.IsTemporary
+	}
+
+	var netError net.Error
+	if errors.As(err, &netError) && netError.Timeout() {
+		return true
+	}
+	return errors.Is(err, errStalled) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// retry makes the attempt until it succeeds, fails with an error which is not transient or the retries are exhausted.
+// The delay between attempts doubles each time.
+func retry(rawURL string, attempt func() error) error {
+	delay := time.Second
+	for retries := 0; ; retries++ {
+		err := attempt()
+		if err == nil || retries >= httpSettings.Retries || !isTransient(err) {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "Download of %s failed, retrying in %s: %s\n", rawURL, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// stallTimeoutReader fails the reads of a response body when no data is received for the timeout.
+type stallTimeoutReader struct {
+	reader  io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+	stalled int32
+}
+
+// newStallTimeoutReader reads the body, cancelling the request when it stalls.
+func newStallTimeoutReader(body io.Reader, cancel context.CancelFunc) *stallTimeoutReader {
+	r := &stallTimeoutReader{reader: body, timeout: httpSettings.Timeout}
+	if r.timeout > 0 {
+		r.timer = time.AfterFunc(r.timeout, func() {
+			atomic.StoreInt32(&r.stalled, 1)
+			cancel()
+		})
+	}
+	return r
+}
+
+func (r *stallTimeoutReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if r.timer != nil {
+		if err != nil && atomic.LoadInt32(&r.stalled) == 1 {
+			return n, fmt.Errorf("no data received for %s: %w", r.timeout, errStalled)
+		}
+		r.timer.Reset(r.timeout)
+	}
+	return n, err
+}
+
+func (r *stallTimeoutReader) stop() {
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+}
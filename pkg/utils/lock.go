@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// FileLock is an exclusive advisory lock held on a file, shared between devcore processes.
+type FileLock struct {
+	file *os.File
+}
+
+// LockFile acquires the exclusive lock of the given file, creating the file if needed. When another process holds the
+// lock, waiting is called before blocking until the lock is released. Nothing is locked in dry run mode.
+func LockFile(path string, waiting func()) (*FileLock, error) {
+	if DryRun {
+		return &FileLock{}, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		if waiting != nil {
+			waiting()
+		}
+		err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &FileLock{file: file}, nil
+}
+
+// TryLockFile acquires the exclusive lock of the given file, creating the file if needed, unless another process holds
+// it: nil is returned then. Nothing is locked in dry run mode.
+func TryLockFile(path string) (*FileLock, error) {
+	if DryRun {
+		return &FileLock{}, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		file.Close()
+		return nil, nil
+	} else if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &FileLock{file: file}, nil
+}
+
+// Unlock releases the lock. The lock file is kept so that every process locks the same file.
+func (l *FileLock) Unlock() error {
+	if l.file == nil {
+		return nil
+	}
+	defer l.file.Close()
+	return syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,86 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// progressThreshold is the size from which the progress of a download is displayed.
+const progressThreshold = 1 << 20
+
+const progressBarWidth = 30
+
+// progress displays how much of a download is done: as a bar redrawn in place on a terminal, as a line every 10%
+// otherwise.
+type progress struct {
+	out      io.Writer
+	terminal bool
+	name     string
+	done     int64
+	total    int64
+	shown    int
+}
+
+// newProgress returns the progress of the download of a file, or nil when the file is too small or its size is
+// unknown. offset is the size already downloaded when resuming.
+func newProgress(name string, offset int64, size int64) *progress {
+	if size < 0 || offset+size < progressThreshold {
+		return nil
+	}
+	return &progress{out: os.Stderr, terminal: IsTerminal(os.Stderr), name: name, done: offset, total: offset + size, shown: -1}
+}
+
+// IsTerminal tells if the file is a terminal rather than a pipe or a regular file.
+func IsTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (p *progress) Write(data []byte) (int, error) {
+	p.done += int64(len(data))
+	p.show()
+	return len(data), nil
+}
+
+func (p *progress) show() {
+	percent := int(p.done * 100 / p.total)
+	if p.terminal {
+		if percent == p.shown {
+			return
+		}
+		filled := percent * progressBarWidth / 100
+		fmt.Fprintf(p.out, "\r%s [%s%s] %3d%% %s / %s", p.name, strings.Repeat("=", filled),
+			strings.Repeat(" ", progressBarWidth-filled), percent, HumanSize(p.done), HumanSize(p.total))
+	} else {
+		percent -= percent % 10
+		if percent == p.shown {
+			return
+		}
+		fmt.Fprintf(p.out, "Downloading %s: %d%%\n", p.name, percent)
+	}
+	p.shown = percent
+}
+
+// finish ends the line of the progress bar.
+func (p *progress) finish() {
+	if p.terminal {
+		fmt.Fprintln(p.out)
+	}
+}
+
+// HumanSize formats a size in bytes using binary units, e.g. 1.5 MiB.
+func HumanSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,160 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Command describes a process run by a Runner.
+type Command struct {
+	Name string
+	Args []string
+	// Dir is the working directory of the process, the one of devcore when empty.
+	Dir string
+	// Env are variables added to the environment of devcore, which the process inherits.
+	Env []string
+	// Stdin is the input of the process, none when nil.
+	Stdin io.Reader
+	// Timeout stops the process when it runs for longer, when positive.
+	Timeout time.Duration
+}
+
+// Line returns the command line of the command, for messages.
+func (c Command) Line() string {
+	return strings.Join(append([]string{c.Name}, c.Args...), " ")
+}
+
+// Result is the outcome of a process run to completion.
+type Result struct {
+	// Stdout and Stderr are the captured outputs, empty when they are streamed.
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// ExitError is returned when a process exits with a non-zero code.
+type ExitError struct {
+	Command  string
+	ExitCode int
+	// Stderr is the captured error output of the process, empty when it is streamed.
+	Stderr string
+}
+
+func (e *ExitError) Error() string {
+	message := fmt.Sprintf("`%s` exited with code %d", e.Command, e.ExitCode)
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		message += ": " + stderr
+	}
+	return message
+}
+
+// Runner runs processes.
+type Runner interface {
+	// Run runs the command to completion, streaming its output.
+	Run(ctx context.Context, command Command) (Result, error)
+	// Output runs the command to completion, capturing its output.
+	Output(ctx context.Context, command Command) (Result, error)
+	// Start starts the command without waiting for it, streaming its output, and returns its pid.
+	Start(command Command) (int, error)
+}
+
+// ExecRunner runs actual processes, or prints them like a DryRunner in dry run mode.
+type ExecRunner struct {
+	// Stdout and Stderr receive the streamed outputs, the ones of devcore when nil.
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func (r *ExecRunner) Run(ctx context.Context, command Command) (Result, error) {
+	if DryRun {
+		return (&DryRunner{}).Run(ctx, command)
+	}
+	return r.run(ctx, command, r.stdout(), r.stderr())
+}
+
+func (r *ExecRunner) Output(ctx context.Context, command Command) (Result, error) {
+	if DryRun {
+		return (&DryRunner{}).Output(ctx, command)
+	}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	result, err := r.run(ctx, command, stdout, stderr)
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+
+	var exitError *ExitError
+	if errors.As(err, &exitError) {
+		exitError.Stderr = result.Stderr
+	}
+	return result, err
+}
+
+func (r *ExecRunner) Start(command Command) (int, error) {
+	if DryRun {
+		return (&DryRunner{}).Start(command)
+	}
+	c := r.command(context.Background(), command)
+	c.Stdout = r.stdout()
+	c.Stderr = r.stderr()
+	if err := c.Start(); err != nil {
+		return 0, fmt.Errorf("can not start `%s`: %w", command.Line(), err)
+	}
+
+	// Reaps the process when it exits while devcore still runs
+	go c.Wait()
+	return c.Process.Pid, nil
+}
+
+func (r *ExecRunner) run(ctx context.Context, command Command, stdout io.Writer, stderr io.Writer) (Result, error) {
+	if command.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, command.Timeout)
+		defer cancel()
+	}
+
+	c := r.command(ctx, command)
+	c.Stdout = stdout
+	c.Stderr = stderr
+	err := c.Run()
+
+	var exitError *exec.ExitError
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return Result{ExitCode: -1}, fmt.Errorf("`%s` timed out after %s", command.Line(), command.Timeout)
+	} else if ctx.Err() != nil {
+		return Result{ExitCode: -1}, fmt.Errorf("`%s` interrupted: %w", command.Line(), ctx.Err())
+	} else if errors.As(err, &exitError) {
+		return Result{ExitCode: exitError.ExitCode()}, &ExitError{Command: command.Line(), ExitCode: exitError.ExitCode()}
+	} else if err != nil {
+		return Result{ExitCode: -1}, fmt.Errorf("can not run `%s`: %w", command.Line(), err)
+	}
+	return Result{}, nil
+}
+
+func (r *ExecRunner) command(ctx context.Context, command Command) *exec.Cmd {
+	c := exec.CommandContext(ctx, command.Name, command.Args...)
+	c.Dir = command.Dir
+	c.Env = append(os.Environ(), command.Env...)
+	c.Stdin = command.Stdin
+	return c
+}
+
+func (r *ExecRunner) stdout() io.Writer {
+	if r.Stdout != nil {
+		return r.Stdout
+	}
+	return os.Stdout
+}
+
+func (r *ExecRunner) stderr() io.Writer {
+	if r.Stderr != nil {
+		return r.Stderr
+	}
+	return os.Stderr
+}
@@ -1,217 +1,215 @@
 package pkg
 
 import (
-	"archive/tar"
-	"archive/zip"
-	"compress/gzip"
+	"bytes"
+	"context"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
 )
 
-func ExecCommandInDir(inDir string, name string, args ...string) (string, error) {
-	c := exec.Command(name, args...)
-	if inDir != "" {
-		c.Dir = inDir
-	}
-
-	return "", DisplayCommandOutput(c)
-}
-
-func DisplayCommandOutput(c *exec.Cmd) error {
-	c.Stdout = os.Stdout
-	c.Stderr = os.Stderr
-
-	return c.Start()
-}
-
-func ExecCommand(name string, args ...string) (string, error) {
-	return ExecCommandInDir("", name, args...)
-}
-
-func ToClipboard(content []byte) {
-	arch := runtime.GOOS
-	var copyCmd *exec.Cmd
-
-	if arch == "darwin" {
-		copyCmd = exec.Command("pbcopy")
-	} else if arch == "linux" {
-		copyCmd = exec.Command("xclip", "-selection", "c")
-	}
-
-	in, err := copyCmd.StdinPipe()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	if err := copyCmd.Start(); err != nil {
-		log.Fatal(err)
-	}
-
-	if _, err := in.Write([]byte(content)); err != nil {
-		log.Fatal(err)
-	}
-
-	if err := in.Close(); err != nil {
-		log.Fatal(err)
+// ToClipboard copies the content to the clipboard of the system.
+func ToClipboard(runner Runner, content []byte) error {
+	command := Command{Stdin: bytes.NewReader(content)}
+	switch runtime.GOOS {
+	case "darwin":
+		command.Name = "pbcopy"
+	case "linux":
+		command.Name = "xclip"
+		command.Args = []string{"-selection", "c"}
+	default:
+		return fmt.Errorf("copying to the clipboard is not supported on %s", runtime.GOOS)
 	}
 
-	copyCmd.Wait()
+	_, err := runner.Output(context.Background(), command)
+	return err
 }
 
-func OpenBrowser(url string) {
-	var err error
-
+// OpenBrowser opens the URL in the default browser of the system, without waiting for the browser to exit.
+func OpenBrowser(runner Runner, url string) error {
+	var command Command
 	switch runtime.GOOS {
 	case "linux":
-		err = exec.Command("xdg-open", url).Start()
+		command = Command{Name: "xdg-open", Args: []string{url}}
 	case "windows":
-		err = exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+		command = Command{Name: "rundll32", Args: []string{"url.dll,FileProtocolHandler", url}}
 	case "darwin":
-		err = exec.Command("open", url).Start()
+		command = Command{Name: "open", Args: []string{url}}
 	default:
-		err = fmt.Errorf("unsupported platform")
-	}
-	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("opening a browser is not supported on %s", runtime.GOOS)
 	}
-}
 
-func DownloadFile(url string, destinationFile string) error {
-	// Get the data
-	resp, err := http.Get(url)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	// Create the file
-	out, err := os.Create(destinationFile)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	// Write the body to file
-	_, err = io.Copy(out, resp.Body)
-	out.Chmod(0755)
+	_, err := runner.Start(command)
 	return err
 }
 
-func Expand(archive string, destinationDir string) error {
-	if strings.HasSuffix(archive, ".zip") {
-		return unzip(archive, destinationDir)
-	} else if strings.HasSuffix(archive, ".tar.gz") {
-		return untarGz(archive, destinationDir)
-	} else {
-		return fmt.Errorf("Unknown archive format")
+// DownloadFile downloads a file to the given destination, which is made executable. Transient failures are retried
+// and the progress of large downloads is displayed.
+func DownloadFile(url string, destinationFile string) error {
+	if DryRun {
+		Plan("download %s to %s", url, destinationFile)
+		return nil
 	}
-}
 
-func unzip(archive string, destinationDir string) error {
-	r, err := zip.OpenReader(archive)
-	if err != nil {
-		return err
-	}
-	defer r.Close()
+	return retry(url, func() error {
+		req, cancel, err := newRequest(url)
+		if err != nil {
+			return err
+		}
+		defer cancel()
 
-	for _, f := range r.File {
-		filePath := filepath.Join(destinationDir, f.Name)
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
 
-		if !strings.HasPrefix(filePath, filepath.Clean(destinationDir)+string(os.PathSeparator)) {
-			return fmt.Errorf("%s: Illegal file path", filePath)
+		if err := checkResponse(req, resp, http.StatusOK); err != nil {
+			return err
 		}
 
-		if f.FileInfo().IsDir() {
-			os.MkdirAll(filePath, os.ModePerm)
-			continue
+		out, err := os.Create(destinationFile)
+		if err != nil {
+			return err
 		}
+		defer out.Close()
 
-		if err = os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		if err := copyBody(out, req, resp, cancel, 0); err != nil {
 			return err
 		}
+		return out.Chmod(0755)
+	})
+}
+
+// ResumeDownload downloads a file, resuming the download when the destination file already contains the beginning of
+// it. The download starts over when the server doesn't support range requests, or can't serve the rest of the file.
+// Transient failures are retried, from where the download stopped, and the progress of large downloads is displayed.
+func ResumeDownload(url string, destinationFile string) error {
+	if DryRun {
+		Plan("download %s to %s", url, destinationFile)
+		return nil
+	}
 
-		outFile, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
+	var attempt func() error
+	attempt = func() error {
+		var offset int64
+		if info, err := os.Stat(destinationFile); err == nil {
+			offset = info.Size()
+		}
+
+		req, cancel, err := newRequest(url)
 		if err != nil {
 			return err
 		}
+		defer cancel()
+		if offset > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		}
 
-		rc, err := f.Open()
+		resp, err := httpClient.Do(req)
 		if err != nil {
 			return err
 		}
+		defer resp.Body.Close()
+
+		flags := os.O_WRONLY | os.O_CREATE
+		switch {
+		case resp.StatusCode == http.StatusPartialContent:
+			flags |= os.O_APPEND
+		case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+			// Either the file is already complete or it is longer than the remote one, which may have changed since the
+			// download was interrupted. Its content can't be trusted, the download starts over.
+			if err := os.Remove(destinationFile); err != nil {
+				return err
+			}
+			return attempt()
+		case resp.StatusCode == http.StatusOK:
+			flags |= os.O_TRUNC
+			offset = 0
+		}
+		if err := checkResponse(req, resp, http.StatusOK, http.StatusPartialContent); err != nil {
+			return err
+		}
 
-		_, err = io.Copy(outFile, rc)
-		outFile.Close()
-		rc.Close()
-
+		out, err := os.OpenFile(destinationFile, flags, 0644)
 		if err != nil {
 			return err
 		}
-	}
-	return nil
-}
+		defer out.Close()
 
-func untarGz(archive string, destinationDir string) error {
-	reader, err := os.Open(archive)
-	if err != nil {
-		return err
+		return copyBody(out, req, resp, cancel, offset)
 	}
-	defer reader.Close()
+	return retry(url, attempt)
+}
 
-	gz, err := gzip.NewReader(reader)
-	if err != nil {
-		return err
-	}
-	defer gz.Close()
+// FetchContent downloads a small resource, like a checksum file or an API response, and returns its content.
+// Transient failures are retried.
+func FetchContent(url string) ([]byte, error) {
+	var content []byte
+	err := retry(url, func() error {
+		req, cancel, err := newRequest(url)
+		if err != nil {
+			return err
+		}
+		defer cancel()
 
-	t := tar.NewReader(gz)
-	for {
-		header, err := t.Next()
-		if err == io.EOF {
-			break
-		} else if err != nil {
+		resp, err := httpClient.Do(req)
+		if err != nil {
 			return err
 		}
+		defer resp.Body.Close()
 
-		if header.FileInfo().IsDir() {
-			err = os.MkdirAll(filepath.Join(destinationDir, header.Name), 0755)
-			if err != nil {
-				return err
-			}
-		} else {
-			if strings.ContainsRune(header.Name, os.PathSeparator) {
-				idx := strings.LastIndex(header.Name, string(os.PathSeparator))
-				parent := header.Name[:idx]
-				err = os.MkdirAll(filepath.Join(destinationDir, parent), 0755)
-
-				if err != nil {
-					return err
-				}
-			}
+		if resp.StatusCode != http.StatusOK {
+			return &statusError{URL: req.URL.String(), Status: resp.Status, Code: resp.StatusCode}
+		}
 
-			file, err := os.Create(filepath.Join(destinationDir, header.Name))
-			if err != nil {
-				return err
-			}
-			file.Chmod(0755)
+		body := newStallTimeoutReader(resp.Body, cancel)
+		defer body.stop()
+		content, err = io.ReadAll(body)
+		return err
+	})
+	return content, err
+}
 
-			_, err = io.Copy(file, t)
-			file.Close()
-			if err != nil {
-				return err
-			}
-		}
+// checkResponse fails unless the response has one of the expected statuses and is not an HTML page, which servers
+// answer with for pages like a "not found" one, instead of the requested file.
+func checkResponse(req *http.Request, resp *http.Response, expectedStatuses ...int) error {
+	expected := false
+	for _, status := range expectedStatuses {
+		expected = expected || resp.StatusCode == status
+	}
+	if !expected {
+		return &statusError{URL: req.URL.String(), Status: resp.Status, Code: resp.StatusCode}
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return fmt.Errorf("%s returned an HTML page instead of a file", req.URL)
 	}
 	return nil
 }
 
+// copyBody writes the body of the response to the file, displaying the progress of the download. The request is
+// cancelled when the download stalls. offset is the size already downloaded when resuming.
+func copyBody(out io.Writer, req *http.Request, resp *http.Response, cancel context.CancelFunc, offset int64) error {
+	body := newStallTimeoutReader(resp.Body, cancel)
+	defer body.stop()
+
+	var reader io.Reader = body
+	progress := newProgress(path.Base(req.URL.Path), offset, resp.ContentLength)
+	if progress != nil {
+		reader = io.TeeReader(body, progress)
+		defer progress.finish()
+	}
+
+	_, err := io.Copy(out, reader)
+	return err
+}
+
 func IsLinux() bool {
 	return runtime.GOOS == "linux"
 }
@@ -219,3 +217,51 @@ func IsLinux() bool {
 func IsOSX() bool {
 	return runtime.GOOS == "darwin"
 }
+
+// MoveFile moves a file to the given destination, falling back to a copy when both are not on the same file system.
+func MoveFile(source string, destination string) error {
+	if DryRun {
+		Plan("move %s to %s", source, destination)
+		return nil
+	}
+
+	if err := os.Rename(source, destination); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(destination)
+		return err
+	}
+	return os.Remove(source)
+}
+
+// IsInPath indicates if the given directory is part of the PATH environment variable.
+func IsInPath(dir string) bool {
+	for _, entry := range filepath.SplitList(os.Getenv("PATH")) {
+		if filepath.Clean(entry) == filepath.Clean(dir) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+// Package utilstest provides test doubles for the utilities of devcore.
+package utilstest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	du "io.twasyl/devcore/pkg/utils"
+)
+
+// FakeRunner is an in-memory du.Runner recording the commands it is given and answering them with the results
+// registered for their command lines. Commands without result fail.
+type FakeRunner struct {
+	mutex   sync.Mutex
+	results map[string]du.Result
+	nextPid int
+	// Calls are the commands given to the runner, in order.
+	Calls []du.Command
+	// Streamed receives the outputs of the commands streaming them.
+	Streamed bytes.Buffer
+}
+
+// NewFakeRunner returns a FakeRunner without registered result.
+func NewFakeRunner() *FakeRunner {
+	return &FakeRunner{results: make(map[string]du.Result), nextPid: 1000}
+}
+
+// On registers the result of the command line, e.g. `git pull`. A non-zero exit code makes the command fail.
+func (f *FakeRunner) On(line string, result du.Result) *FakeRunner {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.results[line] = result
+	return f
+}
+
+// Lines returns the command lines given to the runner, in order.
+func (f *FakeRunner) Lines() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	lines := make([]string, 0)
+	for _, command := range f.Calls {
+		lines = append(lines, command.Line())
+	}
+	return lines
+}
+
+func (f *FakeRunner) Run(ctx context.Context, command du.Command) (du.Result, error) {
+	result, err := f.Output(ctx, command)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.Streamed.WriteString(result.Stdout)
+	f.Streamed.WriteString(result.Stderr)
+	return du.Result{ExitCode: result.ExitCode}, err
+}
+
+func (f *FakeRunner) Output(ctx context.Context, command du.Command) (du.Result, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.Calls = append(f.Calls, command)
+
+	if err := ctx.Err(); err != nil {
+		return du.Result{ExitCode: -1}, fmt.Errorf("`%s` interrupted: %w", command.Line(), err)
+	}
+	result, found := f.results[command.Line()]
+	if !found {
+		return du.Result{ExitCode: -1}, fmt.Errorf("can not run `%s`: unexpected command", command.Line())
+	}
+	if result.ExitCode != 0 {
+		return result, &du.ExitError{Command: command.Line(), ExitCode: result.ExitCode, Stderr: result.Stderr}
+	}
+	return result, nil
+}
+
+func (f *FakeRunner) Start(command du.Command) (int, error) {
+	if _, err := f.Output(context.Background(), command); err != nil {
+		return 0, err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.nextPid++
+	return f.nextPid, nil
+}
@@ -0,0 +1,130 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Latest is the specifier matching any stable version.
+const Latest = "latest"
+
+// Constraint restricts the versions accepted for a tool. It is parsed from specifiers like latest, 1.25.x, ~3.9 or
+// ^1.2.
+type Constraint struct {
+	specifier string
+	// prefix holds the numbers a matching version starts with
+	prefix []int
+	// minimum is the lowest matching version, empty when there is none
+	minimum string
+}
+
+// IsExact indicates if the specifier is an exact version rather than a constraint to resolve.
+func IsExact(specifier string) bool {
+	if specifier == Latest || strings.HasPrefix(specifier, "~") || strings.HasPrefix(specifier, "^") {
+		return false
+	}
+	for _, part := range strings.Split(specifier, ".") {
+		if part == "x" || part == "X" || part == "*" {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseConstraint parses a version specifier:
+//   - latest matches any version
+//   - 1.25.x (or 1.25.*) matches the versions starting with 1.25
+//   - ~3.9 matches the versions starting with 3.9, ~3.9.2 the ones starting with 3.9 from 3.9.2
+//   - ^1.2 matches the versions starting with 1 from 1.2
+//   - any other specifier only matches itself
+func ParseConstraint(specifier string) (Constraint, error) {
+	constraint := Constraint{specifier: specifier}
+	if specifier == Latest {
+		return constraint, nil
+	}
+
+	if IsExact(specifier) {
+		numbers, _ := split(specifier)
+		constraint.prefix = numbers
+		constraint.minimum = specifier
+		return constraint, nil
+	}
+
+	var err error
+	switch {
+	case strings.HasPrefix(specifier, "~"):
+		constraint.minimum = specifier[1:]
+		constraint.prefix, err = parseNumbers(specifier[1:])
+		if len(constraint.prefix) > 2 {
+			constraint.prefix = constraint.prefix[:2]
+		}
+	case strings.HasPrefix(specifier, "^"):
+		constraint.minimum = specifier[1:]
+		constraint.prefix, err = parseNumbers(specifier[1:])
+		if len(constraint.prefix) > 1 {
+			constraint.prefix = constraint.prefix[:1]
+		}
+	default:
+		parts := strings.Split(specifier, ".")
+		for index, part := range parts {
+			if part == "x" || part == "X" || part == "*" {
+				if index != len(parts)-1 {
+					return constraint, fmt.Errorf("invalid version specifier: %s", specifier)
+				}
+				parts = parts[:index]
+			}
+		}
+		constraint.prefix, err = parseNumbers(strings.Join(parts, "."))
+	}
+
+	if err != nil || len(constraint.prefix) == 0 {
+		return constraint, fmt.Errorf("invalid version specifier: %s", specifier)
+	}
+	return constraint, nil
+}
+
+// Matches indicates if the version satisfies the constraint.
+func (c Constraint) Matches(version string) bool {
+	if c.specifier == Latest {
+		return true
+	}
+
+	numbers, _ := split(version)
+	for index, number := range c.prefix {
+		if numberAt(numbers, index) != number {
+			return false
+		}
+	}
+	return c.minimum == "" || Compare(version, c.minimum) >= 0
+}
+
+// String returns the specifier the constraint has been parsed from.
+func (c Constraint) String() string {
+	return c.specifier
+}
+
+// Highest returns the highest stable version satisfying the constraint, or an empty string if none does.
+func (c Constraint) Highest(versions []string) string {
+	highest := ""
+	for _, version := range versions {
+		if IsPreRelease(version) || !c.Matches(version) {
+			continue
+		}
+		if highest == "" || Compare(version, highest) > 0 {
+			highest = version
+		}
+	}
+	return highest
+}
+
+func parseNumbers(version string) ([]int, error) {
+	numbers := make([]int, 0)
+	for _, part := range strings.Split(version, ".") {
+		number := 0
+		if _, err := fmt.Sscanf(part, "%d", &number); err != nil || fmt.Sprint(number) != part {
+			return nil, fmt.Errorf("invalid version: %s", version)
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, nil
+}
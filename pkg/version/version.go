@@ -0,0 +1,69 @@
+package version
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Compare compares two versions made of dot separated numbers, like 1.23.6. A leading v is ignored and a pre-release
+// suffix (1.0.0-rc1) or build metadata (3.8.2+g6e3701e) makes a version lower than, respectively equal to, the version
+// without it. The result is 0 if a == b, -1 if a < b and +1 if a > b.
+func Compare(a string, b string) int {
+	aNumbers, aPreRelease := split(a)
+	bNumbers, bPreRelease := split(b)
+
+	for index := 0; index < len(aNumbers) || index < len(bNumbers); index++ {
+		aNumber, bNumber := numberAt(aNumbers, index), numberAt(bNumbers, index)
+		if aNumber < bNumber {
+			return -1
+		} else if aNumber > bNumber {
+			return 1
+		}
+	}
+
+	if aPreRelease == bPreRelease {
+		return 0
+	} else if aPreRelease == "" {
+		return 1
+	} else if bPreRelease == "" {
+		return -1
+	} else if aPreRelease < bPreRelease {
+		return -1
+	}
+	return 1
+}
+
+// IsPreRelease indicates if the version has a pre-release suffix, like 1.0.0-rc1 or 2.0.0-beta.
+func IsPreRelease(version string) bool {
+	_, preRelease := split(version)
+	return preRelease != ""
+}
+
+func split(version string) ([]int, string) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if index := strings.Index(version, "+"); index != -1 {
+		version = version[:index]
+	}
+
+	preRelease := ""
+	if index := strings.Index(version, "-"); index != -1 {
+		version, preRelease = version[:index], version[index+1:]
+	}
+
+	numbers := make([]int, 0)
+	for _, part := range strings.Split(version, ".") {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			number = 0
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, preRelease
+}
+
+func numberAt(numbers []int, index int) int {
+	if index < len(numbers) {
+		return numbers[index]
+	}
+	return 0
+}
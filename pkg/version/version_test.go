@@ -0,0 +1,61 @@
+package version
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.23.6", "1.23.6", 0},
+		{"v1.23.6", "1.23.6", 0},
+		{"1.6", "1.6.0", 0},
+		{"3.8.2+g6e3701e", "3.8.2", 0},
+		{"1.23.6", "1.25.0", -1},
+		{"1.10.0", "1.9.0", 1},
+		{"2.0.0-rc1", "2.0.0", -1},
+		{"2.0.0-rc1", "2.0.0-rc2", -1},
+		{"0.20.0", "0.3.0", 1},
+	}
+
+	for _, test := range tests {
+		if actual := Compare(test.a, test.b); actual != test.expected {
+			t.Errorf("Compare(%s, %s) = %d, expected %d", test.a, test.b, actual, test.expected)
+		}
+	}
+}
+
+func TestConstraintHighest(t *testing.T) {
+	versions := []string{"1.24.3", "1.25.0", "1.25.4", "1.26.0-rc.1", "1.3.0", "2.0.1"}
+	tests := []struct {
+		specifier string
+		expected  string
+	}{
+		{"latest", "2.0.1"},
+		{"1.25.x", "1.25.4"},
+		{"1.x", "1.25.4"},
+		{"~1.24", "1.24.3"},
+		{"~1.25.2", "1.25.4"},
+		{"^1.3", "1.25.4"},
+		{"1.24.3", "1.24.3"},
+		{"3.x", ""},
+	}
+
+	for _, test := range tests {
+		constraint, err := ParseConstraint(test.specifier)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%s) failed: %s", test.specifier, err)
+		}
+		if actual := constraint.Highest(versions); actual != test.expected {
+			t.Errorf("Highest for %s = %s, expected %s", test.specifier, actual, test.expected)
+		}
+	}
+}
+
+func TestParseInvalidConstraint(t *testing.T) {
+	for _, specifier := range []string{"~", "^a.b", "1.x.2", "x"} {
+		if _, err := ParseConstraint(specifier); err == nil {
+			t.Errorf("ParseConstraint(%s) should have failed", specifier)
+		}
+	}
+}